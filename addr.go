@@ -0,0 +1,39 @@
+package udger
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ParseAddr parses an IPv4 or IPv6 address and returns it in the canonical form used for lookups.
+// Surrounding spaces and brackets are ignored, see CanonicalAddr for the normalization.
+func ParseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	return CanonicalAddr(addr), nil
+}
+
+// CanonicalAddr unmaps IPv4-mapped IPv6 addresses (::ffff:1.2.3.4) and strips the IPv6 zone,
+// so every representation of an address matches the same entry of the database.
+func CanonicalAddr(addr netip.Addr) netip.Addr {
+	return addr.Unmap().WithZone("")
+}
+
+// addrFromIP converts a net.IP to its canonical netip.Addr.
+func addrFromIP(ip net.IP) (netip.Addr, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	return CanonicalAddr(addr), true
+}
@@ -0,0 +1,65 @@
+package udger_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "66.249.64.1", want: "66.249.64.1"},
+		{in: " 66.249.64.1\n", want: "66.249.64.1"},
+		{in: "::ffff:66.249.64.1", want: "66.249.64.1"},
+		{in: "::ffff:42f9:4001", want: "66.249.64.1"},
+		{in: "2001:4860:0000:0000:0000:0000:0000:0001", want: "2001:4860::1"},
+		{in: "2001:4860::1%eth0", want: "2001:4860::1"},
+		{in: "[2001:4860::1]", want: "2001:4860::1"},
+	}
+
+	Convey("parse addresses to their canonical form", t, func() {
+		for _, test := range tests {
+			addr, err := udger.ParseAddr(test.in)
+			So(err, ShouldBeNil)
+			So(addr.String(), ShouldEqual, test.want)
+		}
+	})
+
+	Convey("reject invalid addresses", t, func() {
+		for _, in := range []string{"", "66.249.64", "not an ip", "[66.249.64.1"} {
+			_, err := udger.ParseAddr(in)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestLookupAddr(t *testing.T) {
+	Convey("lookup every representation of an address", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		for _, addr := range []netip.Addr{
+			netip.MustParseAddr("66.249.64.1"),
+			netip.MustParseAddr("::ffff:66.249.64.1"),
+		} {
+			info, err := u.LookupAddr(addr)
+			So(err, ShouldBeNil)
+			So(info.Crawler.FamilyCode, ShouldEqual, "googlebot")
+			So(info.DataCenter.NameCode, ShouldEqual, "google")
+		}
+
+		info, err := u.LookupAddr(netip.MustParseAddr("2001:4860::1%eth0"))
+		So(err, ShouldBeNil)
+		So(info.DataCenter.NameCode, ShouldEqual, "google")
+
+		info, err = u.LookupAddr(netip.Addr{})
+		So(err, ShouldBeNil)
+		So(info, ShouldResemble, &udger.IPInfo{})
+	})
+}
@@ -0,0 +1,215 @@
+package udger_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+)
+
+// benchFillers is the number of regexes added to the fixture, about the size of the client regexes of udger.
+const benchFillers = 1000
+
+// benchDB returns the default fixture with more clients and regexes never matching the sample,
+// so the unmatched user agents evaluate as many regexes as with the udger database.
+func benchDB() udgertest.DB {
+	d := udgertest.Default()
+	// the Edge and mobile Chrome regexes must be evaluated before the Chrome one
+	d.Clients = append([]udgertest.Client{
+		{ID: 6, ClassID: 1, Name: "Edge", Engine: "WebKit/Blink", Vendor: "Microsoft Corporation.", Regexes: []string{`/edg\/([0-9\.]+)/si`}},
+		{ID: 7, ClassID: 3, Name: "Chrome Mobile", Engine: "WebKit/Blink", Vendor: "Google Inc.", Regexes: []string{`/android.*chrome\/([0-9\.]+).*mobile/si`}},
+	}, d.Clients...)
+	d.Clients = append(d.Clients, udgertest.Client{ID: 8, ClassID: 1, Name: "Firefox", Engine: "Gecko", Vendor: "Mozilla Foundation", Regexes: []string{`/firefox\/([0-9\.]+)/si`}})
+	for i := 0; i < benchFillers; i++ {
+		d.Clients = append(d.Clients, udgertest.Client{
+			ID:      1000 + i,
+			ClassID: 1,
+			Name:    fmt.Sprintf("Filler %d", i),
+			Regexes: []string{fmt.Sprintf(`/filler%d\/([0-9\.]+)/si`, i)},
+		})
+	}
+	d.OS = append(d.OS, udgertest.OS{ID: 7, Name: "Android", Family: "Android", Regexes: []string{`/android/si`}})
+
+	return d
+}
+
+func benchClient(b *testing.B) udger.Client {
+	b.Helper()
+
+	u, err := udger.New(udgertest.Create(b, benchDB()), udger.WithDriver(udgertest.Driver))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return u
+}
+
+// loadSample reads testdata/ua_sample.txt, repeating each user agent by its weight.
+func loadSample(b *testing.B) []string {
+	b.Helper()
+
+	f, err := os.Open("testdata/ua_sample.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	var uas []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		weight, ua, ok := strings.Cut(line, "\t")
+		n, err := strconv.Atoi(weight)
+		if !ok || err != nil {
+			b.Fatalf("invalid sample line %q", line)
+		}
+		for i := 0; i < n; i++ {
+			uas = append(uas, ua)
+		}
+	}
+	if err := s.Err(); err != nil {
+		b.Fatal(err)
+	}
+
+	return uas
+}
+
+func BenchmarkNew(b *testing.B) {
+	path := udgertest.Create(b, benchDB())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := udger.New(path, udger.WithDriver(udgertest.Driver)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	u := benchClient(b)
+	uas := []struct {
+		name string
+		ua   string
+	}{
+		{name: "desktop", ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"},
+		{name: "mobile", ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 9_2_1 like Mac OS X) AppleWebKit/601.1.46 (KHTML, like Gecko) Mobile/13D15"},
+		{name: "crawler", ua: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+		{name: "unmatched", ua: "python-requests/2.31.0"},
+		{name: "long", ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) " + strings.Repeat("AppleWebKit/537.36 (KHTML, like Gecko) ", 200) + "Chrome/120.0.0.0"},
+	}
+
+	for _, tc := range uas {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := u.Lookup(tc.ua); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	b.Run("sample", func(b *testing.B) {
+		sample := loadSample(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := u.Lookup(sample[i%len(sample)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkLookupIP(b *testing.B) {
+	u := benchClient(b)
+	ips := []struct {
+		name string
+		ip   net.IP
+	}{
+		{name: "v4 hit", ip: net.ParseIP("66.249.64.1")},
+		{name: "v4 range", ip: net.ParseIP("66.249.70.1")},
+		{name: "v6 range", ip: net.ParseIP("2001:4860:1003::1")},
+		{name: "miss", ip: net.ParseIP("10.0.0.1")},
+	}
+
+	for _, tc := range ips {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := u.LookupIP(tc.ip); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLookupParallel(b *testing.B) {
+	u := benchClient(b)
+	sample := loadSample(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if _, err := u.Lookup(sample[i%len(sample)]); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkLookupIPParallel(b *testing.B) {
+	u := benchClient(b)
+	ips := []net.IP{net.ParseIP("66.249.64.1"), net.ParseIP("66.249.70.1"), net.ParseIP("2001:4860:1003::1"), net.ParseIP("10.0.0.1")}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if _, err := u.LookupIP(ips[i%len(ips)]); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkLookupInto(b *testing.B) {
+	sample := loadSample(b)
+
+	for _, size := range []int{0, 1000} {
+		b.Run(fmt.Sprintf("cache %d", size), func(b *testing.B) {
+			u, err := udger.New(udgertest.Create(b, benchDB()), udger.WithDriver(udgertest.Driver), udger.WithLookupCache(size))
+			if err != nil {
+				b.Fatal(err)
+			}
+			l := u.(udger.IntoLookuper)
+
+			var info udger.Info
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := l.LookupInto(sample[i%len(sample)], &info); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
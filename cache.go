@@ -0,0 +1,61 @@
+package udger
+
+import "sync"
+
+// maxNames bounds the number of browser names kept by a nameTable.
+const maxNames = 4096
+
+// nameTable keeps the names of the browsers, the family followed by the version, so the
+// lookups of the common versions do not build them again. Once full, the names of the
+// new versions are built on each lookup.
+type nameTable struct {
+	mu    sync.RWMutex
+	names map[nameKey]string
+}
+
+type nameKey struct {
+	id      int
+	version string
+}
+
+func (t *nameTable) name(id int, family, version string) string {
+	k := nameKey{id: id, version: version}
+	t.mu.RLock()
+	n, ok := t.names[k]
+	t.mu.RUnlock()
+	if ok {
+		return n
+	}
+
+	n = family + " " + version
+	// the key shares the memory of the name instead of retaining the user agent
+	k.version = n[len(family)+1:]
+	t.mu.Lock()
+	if len(t.names) < maxNames {
+		t.names[k] = n
+	}
+	t.mu.Unlock()
+
+	return n
+}
+
+// maxCachedUALength bounds the length of the user agents cached by the lookup cache, the longer
+// ones are looked up on each call so a few huge user agents cannot fill the memory.
+const maxCachedUALength = 512
+
+// stats reports the names built so far, they grow with the versions seen by the lookups.
+func (t *nameTable) stats(c *stringCounter) TableStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return mapStats("browser names", t.names, c)
+}
+
+// newLookupCache returns the cache of the results of Lookup, nil when disabled.
+func newLookupCache(o *options) *lru[string, Info] {
+	if o.lookupCache <= 0 {
+		return nil
+	}
+
+	return newLRU[string, Info](o.lookupCache)
+}
@@ -0,0 +1,187 @@
+package udger
+
+import (
+	"sort"
+	"strings"
+)
+
+// Catalog gives a read only access to the entries of the database, it is implemented by the clients
+// returned by New, NewFromDB and NewFromSnapshot. The crawlers are not available in on demand mode.
+type Catalog interface {
+	// Browsers returns the clients matching the filter, sorted by ID
+	Browsers(f CatalogFilter) []Browser
+	// OperatingSystems returns the operating systems matching the filter, sorted by ID
+	OperatingSystems(f CatalogFilter) []OS
+	// Devices returns the device classes matching the filter, sorted by ID
+	Devices(f CatalogFilter) []Device
+	// Crawlers returns the crawlers matching the filter, sorted by ID
+	Crawlers(f CatalogFilter) []Crawler
+	// BrowserFamilies returns the sorted families of the clients
+	BrowserFamilies() []string
+	// OSFamilies returns the sorted families of the operating systems
+	OSFamilies() []string
+	// CrawlerFamilies returns the sorted families of the crawlers
+	CrawlerFamilies() []string
+}
+
+// CatalogFilter selects the entries of the catalog. Empty fields match every entry, the other fields
+// are compared case insensitively and an entry must match all of them.
+type CatalogFilter struct {
+	// Family matches the family or family code
+	Family string
+	// Vendor matches the vendor (company) or vendor code
+	Vendor string
+	// Class matches the client type and class code, the device class and class code or the crawler class and class code
+	Class string
+	// Code matches the crawler family and vendor codes
+	Code string
+	// Search matches a part of the name
+	Search string
+}
+
+// match reports whether an entry matches the filter, given the values of the entry
+// compared with each field of the filter.
+func (f CatalogFilter) match(family, vendor, class, code, search []string) bool {
+	return matchAny(f.Family, family) &&
+		matchAny(f.Vendor, vendor) &&
+		matchAny(f.Class, class) &&
+		matchAny(f.Code, code) &&
+		containsAny(f.Search, search)
+}
+
+func matchAny(want string, values []string) bool {
+	if want == "" {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(want, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(want string, values []string) bool {
+	if want == "" {
+		return true
+	}
+	want = strings.ToLower(want)
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), want) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func strs(values ...string) []string {
+	return values
+}
+
+// Browsers returns the clients matching the filter, sorted by ID.
+func (u *udger) Browsers(f CatalogFilter) []Browser {
+	var out []Browser
+	for _, b := range u.browsers {
+		b.Type = u.browserTypes[b.typ]
+		b.Class = u.browserClasses[b.typ]
+		b.Name = b.Family
+		if f.match(strs(b.Family), strs(b.Company), strs(b.Type, string(b.Class)), nil, strs(b.Family)) {
+			out = append(out, b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+
+	return out
+}
+
+// OperatingSystems returns the operating systems matching the filter, sorted by ID.
+func (u *udger) OperatingSystems(f CatalogFilter) []OS {
+	var out []OS
+	for _, o := range u.os {
+		if f.match(strs(o.Family), strs(o.Company), nil, nil, strs(o.Name)) {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+
+	return out
+}
+
+// Devices returns the device classes matching the filter, sorted by ID.
+func (u *udger) Devices(f CatalogFilter) []Device {
+	var out []Device
+	for _, d := range u.devices {
+		if f.match(nil, nil, strs(d.Name, string(d.Class)), nil, strs(d.Name)) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+
+	return out
+}
+
+// Crawlers returns the crawlers matching the filter, sorted by ID.
+func (u *udger) Crawlers(f CatalogFilter) []Crawler {
+	var out []Crawler
+	for _, c := range u.crawlers {
+		class := u.crawlerClasses[c.ClassID]
+		if f.match(
+			strs(c.Family, c.FamilyCode),
+			strs(c.Vendor, c.VendorCode),
+			strs(class.CrawlerClassification, class.CrawlerClassificationCode),
+			strs(c.FamilyCode, c.VendorCode),
+			strs(c.Name, c.UA),
+		) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+
+	return out
+}
+
+// BrowserFamilies returns the sorted families of the clients.
+func (u *udger) BrowserFamilies() []string {
+	families := make([]string, 0, len(u.browsers))
+	for _, b := range u.browsers {
+		families = append(families, b.Family)
+	}
+
+	return sortedUnique(families)
+}
+
+// OSFamilies returns the sorted families of the operating systems.
+func (u *udger) OSFamilies() []string {
+	families := make([]string, 0, len(u.os))
+	for _, o := range u.os {
+		families = append(families, o.Family)
+	}
+
+	return sortedUnique(families)
+}
+
+// CrawlerFamilies returns the sorted families of the crawlers.
+func (u *udger) CrawlerFamilies() []string {
+	families := make([]string, 0, len(u.crawlers))
+	for _, c := range u.crawlers {
+		families = append(families, c.Family)
+	}
+
+	return sortedUnique(families)
+}
+
+func sortedUnique(values []string) []string {
+	sort.Strings(values)
+
+	out := values[:0]
+	for i, v := range values {
+		if v == "" || (i > 0 && v == values[i-1]) {
+			continue
+		}
+		out = append(out, v)
+	}
+
+	return out
+}
@@ -0,0 +1,57 @@
+package udger_test
+
+import (
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCatalog(t *testing.T) {
+	Convey("query the catalog", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		c, ok := u.(udger.Catalog)
+		So(ok, ShouldBeTrue)
+
+		Convey("list the browsers", func() {
+			browsers := c.Browsers(udger.CatalogFilter{})
+			So(browsers, ShouldHaveLength, 5)
+			So(browsers[1].ID, ShouldEqual, 2)
+			So(browsers[1].Name, ShouldEqual, "Chrome")
+			So(browsers[1].Type, ShouldEqual, "Browser")
+			So(browsers[1].Class, ShouldEqual, udger.ClientClassBrowser)
+			So(browsers[1].Company, ShouldEqual, "Google Inc.")
+
+			So(c.Browsers(udger.CatalogFilter{Class: "library"}), ShouldHaveLength, 1)
+			So(c.Browsers(udger.CatalogFilter{Class: string(udger.ClientClassMobileBrowser)}), ShouldHaveLength, 1)
+			So(c.Browsers(udger.CatalogFilter{Vendor: "google inc.", Class: "Browser"}), ShouldHaveLength, 1)
+			So(c.Browsers(udger.CatalogFilter{Search: "chr"}), ShouldHaveLength, 1)
+			So(c.Browsers(udger.CatalogFilter{Code: "chrome"}), ShouldBeEmpty)
+			So(c.BrowserFamilies(), ShouldResemble, []string{"Chrome", "IE", "Opera", "Safari mobile", "curl"})
+		})
+
+		Convey("list the operating systems", func() {
+			So(c.OperatingSystems(udger.CatalogFilter{}), ShouldHaveLength, 5)
+			So(c.OperatingSystems(udger.CatalogFilter{Family: "windows"})[0].Name, ShouldEqual, "Windows 7")
+			So(c.OSFamilies(), ShouldResemble, []string{"Linux", "Nintendo", "OS X", "Windows", "iOS"})
+		})
+
+		Convey("list the devices", func() {
+			So(c.Devices(udger.CatalogFilter{}), ShouldHaveLength, 3)
+			So(c.Devices(udger.CatalogFilter{Class: "smartphone"}), ShouldResemble, []udger.Device{{ID: 3, Name: "Smartphone", Class: udger.DeviceClassSmartphone, Icon: "phone.png"}})
+			So(c.Devices(udger.CatalogFilter{Class: string(udger.DeviceClassGameConsole)})[0].ID, ShouldEqual, 5)
+		})
+
+		Convey("list the crawlers", func() {
+			So(c.Crawlers(udger.CatalogFilter{}), ShouldHaveLength, 2)
+			So(c.Crawlers(udger.CatalogFilter{Class: "search_engine_bot"}), ShouldHaveLength, 2)
+			So(c.Crawlers(udger.CatalogFilter{Family: "bingbot"})[0].ID, ShouldEqual, 6)
+			So(c.Crawlers(udger.CatalogFilter{Vendor: "google_inc", Code: "googlebot"})[0].ID, ShouldEqual, 3)
+			So(c.Crawlers(udger.CatalogFilter{Search: "bing.com"}), ShouldHaveLength, 1)
+			So(c.CrawlerFamilies(), ShouldResemble, []string{"Bingbot", "Googlebot"})
+		})
+	})
+}
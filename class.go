@@ -0,0 +1,67 @@
+package udger
+
+import "strings"
+
+// ClientClass is the code of a client class of udger_client_class. Unlike the IDs and names,
+// the codes are stable across the versions of the database.
+type ClientClass string
+
+// Client classes of the udger database.
+const (
+	ClientClassBrowser             ClientClass = "browser"
+	ClientClassOfflineBrowser      ClientClass = "offline_browser"
+	ClientClassMobileBrowser       ClientClass = "mobile_browser"
+	ClientClassEmailClient         ClientClass = "email_client"
+	ClientClassLibrary             ClientClass = "library"
+	ClientClassWAPBrowser          ClientClass = "wap_browser"
+	ClientClassValidator           ClientClass = "validator"
+	ClientClassFeedReader          ClientClass = "feed_reader"
+	ClientClassMultimediaPlayer    ClientClass = "multimedia_player"
+	ClientClassOther               ClientClass = "other"
+	ClientClassUseragentAnonymizer ClientClass = "useragent_anonymizer"
+	ClientClassCrawler             ClientClass = "crawler"
+	ClientClassUnrecognized        ClientClass = "unrecognized"
+)
+
+// DeviceClass is the code of a device class of udger_deviceclass_list.
+type DeviceClass string
+
+// Device classes of the udger database.
+const (
+	DeviceClassDesktop          DeviceClass = "desktop"
+	DeviceClassSmartphone       DeviceClass = "smartphone"
+	DeviceClassTablet           DeviceClass = "tablet"
+	DeviceClassGameConsole      DeviceClass = "game_console"
+	DeviceClassSmartTV          DeviceClass = "smart_tv"
+	DeviceClassWearableComputer DeviceClass = "wearable_computer"
+	DeviceClassPDA              DeviceClass = "pda"
+	DeviceClassOther            DeviceClass = "other"
+	DeviceClassUnrecognized     DeviceClass = "unrecognized"
+)
+
+// Codes of the IP classes of udger_ip_class, see IPClass.IPClassificationCode.
+const (
+	IPClassCrawler               = "crawler"
+	IPClassCrossCrawler          = "cross_crawler"
+	IPClassTorExitNode           = "tor_exit_node"
+	IPClassWebProxy              = "web_proxy"
+	IPClassCGIProxy              = "cgi_proxy"
+	IPClassVPNService            = "vpn_service"
+	IPClassFakeCrawler           = "fake_crawler"
+	IPClassWebScraper            = "web_scraper"
+	IPClassKnownAttackSource     = "known_attack_source"
+	IPClassKnownAttackSourceHTTP = "known_attack_source_http"
+	IPClassKnownAttackSourceMail = "known_attack_source_mail"
+	IPClassKnownAttackSourceSSH  = "known_attack_source_ssh"
+)
+
+// The IP classes shared by the predicates of IPInfo and the default policy of Classify.
+var (
+	crawlerIPClasses = []string{IPClassCrawler, IPClassCrossCrawler}
+	proxyIPClasses   = []string{IPClassWebProxy, IPClassCGIProxy}
+)
+
+// classCode returns the code of a class defined by a custom rule without one, from its name.
+func classCode(name string) ClientClass {
+	return ClientClass(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_"))
+}
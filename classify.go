@@ -0,0 +1,164 @@
+package udger
+
+import "fmt"
+
+// Verdict is the traffic quality class of a client.
+type Verdict int
+
+// Verdicts returned by Classify.
+const (
+	VerdictUnknown Verdict = iota
+	VerdictHuman
+	VerdictGoodBot
+	VerdictBadBot
+	VerdictDatacenter
+	VerdictAnonymizer
+)
+
+var verdictNames = map[Verdict]string{
+	VerdictUnknown:    "unknown",
+	VerdictHuman:      "human",
+	VerdictGoodBot:    "good_bot",
+	VerdictBadBot:     "bad_bot",
+	VerdictDatacenter: "datacenter",
+	VerdictAnonymizer: "anonymizer",
+}
+
+// String returns the code of the verdict.
+func (v Verdict) String() string {
+	if s, ok := verdictNames[v]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("Verdict(%d)", int(v))
+}
+
+// MarshalText encodes the verdict as its code.
+func (v Verdict) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Classification is the result of Classify.
+type Classification struct {
+	Verdict Verdict  `json:"verdict"`
+	Reasons []string `json:"reasons"`
+}
+
+// Policy configures which combinations of client class, IP class and crawler class lead to each verdict.
+// The keys are the codes of the udger database, e.g. IPClass.IPClassificationCode or Browser.Class.
+type Policy struct {
+	// AnonymizerIPClasses are the IP classes of anonymizing networks, like tor or proxies
+	AnonymizerIPClasses map[string]bool
+	// BadIPClasses are the IP classes of known bad actors
+	BadIPClasses map[string]bool
+	// CrawlerIPClasses are the IP classes of crawlers
+	CrawlerIPClasses map[string]bool
+	// GoodCrawlerClasses are the crawler classes accepted as good bots, the other crawlers are bad bots
+	GoodCrawlerClasses map[string]bool
+	// HumanClientClasses are the client classes used by humans, see Browser.Class
+	HumanClientClasses map[ClientClass]bool
+	// AnonymizerClientClasses are the client classes hiding the real client
+	AnonymizerClientClasses map[ClientClass]bool
+	// DatacenterIsBot classifies the human clients in a datacenter as bad bots instead of datacenter traffic
+	DatacenterIsBot bool
+}
+
+// DefaultPolicy returns the default classification policy, it can be modified freely.
+func DefaultPolicy() Policy {
+	return Policy{
+		AnonymizerIPClasses: set(append([]string{IPClassTorExitNode, IPClassVPNService}, proxyIPClasses...)...),
+		BadIPClasses: set(
+			IPClassKnownAttackSource,
+			IPClassKnownAttackSourceHTTP,
+			IPClassKnownAttackSourceMail,
+			IPClassKnownAttackSourceSSH,
+			IPClassFakeCrawler,
+			IPClassWebScraper,
+		),
+		CrawlerIPClasses: set(crawlerIPClasses...),
+		GoodCrawlerClasses: set(
+			"search_engine_bot",
+			"site_monitor",
+			"link_checker",
+			"feed_fetcher",
+			"validator",
+		),
+		HumanClientClasses: set(
+			ClientClassBrowser,
+			ClientClassMobileBrowser,
+			ClientClassWAPBrowser,
+			ClientClassEmailClient,
+			ClientClassMultimediaPlayer,
+		),
+		AnonymizerClientClasses: set(ClientClassUseragentAnonymizer),
+	}
+}
+
+func set[K comparable](keys ...K) map[K]bool {
+	m := make(map[K]bool, len(keys))
+	for _, k := range keys {
+		m[k] = true
+	}
+
+	return m
+}
+
+// Classify returns the traffic quality verdict of a client from the results of Lookup and LookupIP,
+// either can be nil. The reasons list the facts the verdict is based on.
+//
+// The rules are evaluated in order: anonymizing or bad IP classes, known crawlers,
+// anonymizing or non human client classes, datacenters, and finally human client classes.
+func Classify(info *Info, ipInfo *IPInfo, policy Policy) Classification {
+	var clientClass ClientClass
+	if info != nil {
+		clientClass = info.Browser.Class
+	}
+	var ipClass, crawlerClass string
+	var crawler, datacenter bool
+	if ipInfo != nil {
+		ipClass = ipInfo.IPClass.IPClassificationCode
+		crawlerClass = ipInfo.CrawlerClass.CrawlerClassificationCode
+		crawler = ipInfo.Crawler.ID != 0 || policy.CrawlerIPClasses[ipClass]
+		datacenter = ipInfo.DataCenter.ID != 0
+	}
+
+	switch {
+	case policy.AnonymizerIPClasses[ipClass]:
+		return verdict(VerdictAnonymizer, "ip class "+ipClass+" is an anonymizer")
+
+	case policy.BadIPClasses[ipClass]:
+		return verdict(VerdictBadBot, "ip class "+ipClass+" is a bad actor")
+
+	case crawler && policy.GoodCrawlerClasses[crawlerClass]:
+		return verdict(VerdictGoodBot, "ip of a known crawler", "crawler class "+crawlerClass+" is accepted")
+
+	case crawler:
+		reason := "crawler class " + crawlerClass + " is not accepted"
+		if crawlerClass == "" {
+			reason = "crawler class is unknown"
+		}
+		return verdict(VerdictBadBot, "ip of a known crawler", reason)
+
+	case policy.AnonymizerClientClasses[clientClass]:
+		return verdict(VerdictAnonymizer, "client class "+string(clientClass)+" is an anonymizer")
+
+	case clientClass != "" && !policy.HumanClientClasses[clientClass]:
+		return verdict(VerdictBadBot, "client class "+string(clientClass)+" is not used by humans")
+
+	case datacenter && policy.DatacenterIsBot:
+		return verdict(VerdictBadBot, "ip in datacenter "+ipInfo.DataCenter.NameCode)
+
+	case datacenter:
+		return verdict(VerdictDatacenter, "ip in datacenter "+ipInfo.DataCenter.NameCode)
+
+	case clientClass != "":
+		return verdict(VerdictHuman, "client class "+string(clientClass)+" is used by humans")
+
+	default:
+		return verdict(VerdictUnknown, "client class and ip are unknown")
+	}
+}
+
+func verdict(v Verdict, reasons ...string) Classification {
+	return Classification{Verdict: v, Reasons: reasons}
+}
@@ -0,0 +1,128 @@
+package udger_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/msales/udger"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func browserInfo(class udger.ClientClass) *udger.Info {
+	return &udger.Info{Browser: udger.Browser{Class: class}}
+}
+
+func ipInfo(ipClass string, crawlerClass string, datacenter string) *udger.IPInfo {
+	info := &udger.IPInfo{IPClass: udger.IPClass{IPClassificationCode: ipClass}}
+	if crawlerClass != "" {
+		info.Crawler = udger.Crawler{ID: 1}
+		info.CrawlerClass = udger.CrawlerClass{CrawlerClassificationCode: crawlerClass}
+	}
+	if datacenter != "" {
+		info.DataCenter = udger.DataCenter{ID: 1, NameCode: datacenter}
+	}
+
+	return info
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		info   *udger.Info
+		ipInfo *udger.IPInfo
+		policy func(*udger.Policy)
+		want   udger.Verdict
+	}{
+		{name: "nothing known", want: udger.VerdictUnknown},
+		{name: "empty results", info: &udger.Info{}, ipInfo: &udger.IPInfo{}, want: udger.VerdictUnknown},
+		{name: "desktop browser", info: browserInfo(udger.ClientClassBrowser), want: udger.VerdictHuman},
+		{name: "mobile browser", info: browserInfo(udger.ClientClassMobileBrowser), ipInfo: ipInfo("", "", ""), want: udger.VerdictHuman},
+		{name: "browser on unrecognized ip", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("unrecognized", "", ""), want: udger.VerdictHuman},
+		{name: "library", info: browserInfo(udger.ClientClassLibrary), want: udger.VerdictBadBot},
+		{name: "validator", info: browserInfo(udger.ClientClassValidator), ipInfo: ipInfo("", "", ""), want: udger.VerdictBadBot},
+		{name: "useragent anonymizer", info: browserInfo(udger.ClientClassUseragentAnonymizer), want: udger.VerdictAnonymizer},
+		{name: "browser over tor", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("tor_exit_node", "", ""), want: udger.VerdictAnonymizer},
+		{name: "browser over web proxy", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("web_proxy", "", ""), want: udger.VerdictAnonymizer},
+		{name: "browser over cgi proxy", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("cgi_proxy", "", ""), want: udger.VerdictAnonymizer},
+		{name: "tor wins over crawler", ipInfo: ipInfo("tor_exit_node", "search_engine_bot", ""), want: udger.VerdictAnonymizer},
+		{name: "attack source", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("known_attack_source_http", "", ""), want: udger.VerdictBadBot},
+		{name: "fake crawler", ipInfo: ipInfo("fake_crawler", "", ""), want: udger.VerdictBadBot},
+		{name: "search engine", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("crawler", "search_engine_bot", "google"), want: udger.VerdictGoodBot},
+		{name: "site monitor", ipInfo: ipInfo("crawler", "site_monitor", ""), want: udger.VerdictGoodBot},
+		{name: "scraper crawler", ipInfo: ipInfo("crawler", "site_grabber", ""), want: udger.VerdictBadBot},
+		{name: "crawler ip without crawler", ipInfo: ipInfo("cross_crawler", "", ""), want: udger.VerdictBadBot},
+		{name: "browser in datacenter", info: browserInfo(udger.ClientClassBrowser), ipInfo: ipInfo("", "", "amazon_aws"), want: udger.VerdictDatacenter},
+		{name: "unknown client in datacenter", ipInfo: ipInfo("", "", "amazon_aws"), want: udger.VerdictDatacenter},
+		{name: "library in datacenter", info: browserInfo(udger.ClientClassLibrary), ipInfo: ipInfo("", "", "amazon_aws"), want: udger.VerdictBadBot},
+		{
+			name:   "datacenter as bot",
+			info:   browserInfo(udger.ClientClassBrowser),
+			ipInfo: ipInfo("", "", "amazon_aws"),
+			policy: func(p *udger.Policy) { p.DatacenterIsBot = true },
+			want:   udger.VerdictBadBot,
+		},
+		{
+			name:   "custom anonymizer ip class",
+			info:   browserInfo(udger.ClientClassBrowser),
+			ipInfo: ipInfo("vpn", "", ""),
+			policy: func(p *udger.Policy) { p.AnonymizerIPClasses["vpn"] = true },
+			want:   udger.VerdictAnonymizer,
+		},
+		{
+			name:   "custom accepted crawler class",
+			ipInfo: ipInfo("crawler", "site_grabber", ""),
+			policy: func(p *udger.Policy) { p.GoodCrawlerClasses["site_grabber"] = true },
+			want:   udger.VerdictGoodBot,
+		},
+		{
+			name:   "custom human client class",
+			info:   browserInfo(udger.ClientClassFeedReader),
+			policy: func(p *udger.Policy) { p.HumanClientClasses[udger.ClientClassFeedReader] = true },
+			want:   udger.VerdictHuman,
+		},
+	}
+
+	Convey("classify clients", t, func() {
+		for _, test := range tests {
+			test := test
+			Convey(test.name, func() {
+				policy := udger.DefaultPolicy()
+				if test.policy != nil {
+					test.policy(&policy)
+				}
+
+				got := udger.Classify(test.info, test.ipInfo, policy)
+				So(got.Verdict, ShouldEqual, test.want)
+				So(got.Reasons, ShouldNotBeEmpty)
+			})
+		}
+	})
+
+	Convey("the default policy agrees with the predicates", t, func() {
+		policy := udger.DefaultPolicy()
+		for _, code := range []string{
+			udger.IPClassCrawler, udger.IPClassCrossCrawler, udger.IPClassTorExitNode, udger.IPClassWebProxy,
+			udger.IPClassCGIProxy, udger.IPClassVPNService, udger.IPClassFakeCrawler,
+		} {
+			info := ipInfo(code, "", "")
+			So(policy.AnonymizerIPClasses[code], ShouldEqual, info.IsTor() || info.IsProxy() || code == udger.IPClassVPNService)
+			So(policy.CrawlerIPClasses[code], ShouldEqual, info.IsBot())
+		}
+	})
+
+	Convey("the default policy is not shared", t, func() {
+		policy := udger.DefaultPolicy()
+		policy.HumanClientClasses[udger.ClientClassLibrary] = true
+
+		So(udger.DefaultPolicy().HumanClientClasses[udger.ClientClassLibrary], ShouldBeFalse)
+	})
+
+	Convey("encode the classification", t, func() {
+		got := udger.Classify(browserInfo(udger.ClientClassBrowser), nil, udger.DefaultPolicy())
+
+		b, err := json.Marshal(got)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"verdict":"human","reasons":["client class browser is used by humans"]}`)
+		So(udger.Verdict(42).String(), ShouldEqual, "Verdict(42)")
+	})
+}
@@ -0,0 +1,176 @@
+package udger
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrReloadUnsupported is returned by Reload when the client was not loaded from a database.
+var ErrReloadUnsupported = errors.New("udger: the client can not be reloaded")
+
+// Reloader is implemented by the clients able to load their database again, e.g. after it was updated.
+type Reloader interface {
+	// Reload loads the database again and swaps it in, the lookups in progress complete on the previous one.
+	// The previous database is kept when the load fails.
+	Reload() error
+}
+
+// client implements Client on the current database, swapped by Reload.
+type client struct {
+	// load loads the database again, nil when the client can not be reloaded
+	load func() (*udger, error)
+	opts *options
+	// mu serializes the reloads and Close
+	mu   sync.Mutex
+	data atomic.Pointer[udger]
+}
+
+func newClient(u *udger, load func() (*udger, error)) *client {
+	c := &client{load: load, opts: u.opts}
+	c.data.Store(u)
+
+	return c
+}
+
+// acquire returns the current database, in on demand mode it is kept open until release.
+func (c *client) acquire() *udger {
+	for {
+		u := c.data.Load()
+		if u.store == nil {
+			return u
+		}
+
+		u.mu.RLock()
+		// a database closed by Close stays the current one and reports the errors of the store
+		if !u.closed || c.data.Load() == u {
+			return u
+		}
+		// swapped and closed by a reload
+		u.mu.RUnlock()
+	}
+}
+
+func (u *udger) release() {
+	if u.store != nil {
+		u.mu.RUnlock()
+	}
+}
+
+// Reload loads the database again and swaps it in, the previous one is closed once the lookups in progress complete.
+func (c *client) Reload() error {
+	if c.load == nil {
+		return ErrReloadUnsupported
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	u, err := c.load()
+	c.opts.observeLoad(start, u, true, err)
+	if err != nil {
+		return err
+	}
+
+	return c.data.Swap(u).Close()
+}
+
+// Close releases the database handle kept open in on demand mode.
+func (c *client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.data.Load().Close()
+}
+
+// Lookup one user agent and return a Info struct who contains all the metadata possible for the UA.
+func (c *client) Lookup(ua string) (*Info, error) {
+	u := c.acquire()
+	defer u.release()
+
+	return u.Lookup(ua)
+}
+
+// LookupInto gathers information about the client using the provided user agent into dst.
+func (c *client) LookupInto(ua string, dst *Info) error {
+	u := c.acquire()
+	defer u.release()
+
+	return u.LookupInto(ua, dst)
+}
+
+// LookupIP gathers information about the client using the provided IP.
+func (c *client) LookupIP(ip net.IP) (*IPInfo, error) {
+	u := c.acquire()
+	defer u.release()
+
+	return u.LookupIP(ip)
+}
+
+// LookupAddr gathers information about the client using the provided address.
+func (c *client) LookupAddr(addr netip.Addr) (*IPInfo, error) {
+	u := c.acquire()
+	defer u.release()
+
+	return u.LookupAddr(addr)
+}
+
+// MemoryStats reports the number of entries and the approximate memory used by each table loaded in memory.
+func (c *client) MemoryStats() MemoryStats {
+	return c.data.Load().MemoryStats()
+}
+
+// Browsers returns the clients matching the filter, sorted by ID.
+func (c *client) Browsers(f CatalogFilter) []Browser {
+	return c.data.Load().Browsers(f)
+}
+
+// OperatingSystems returns the operating systems matching the filter, sorted by ID.
+func (c *client) OperatingSystems(f CatalogFilter) []OS {
+	return c.data.Load().OperatingSystems(f)
+}
+
+// Devices returns the device classes matching the filter, sorted by ID.
+func (c *client) Devices(f CatalogFilter) []Device {
+	return c.data.Load().Devices(f)
+}
+
+// Crawlers returns the crawlers matching the filter, sorted by ID.
+func (c *client) Crawlers(f CatalogFilter) []Crawler {
+	return c.data.Load().Crawlers(f)
+}
+
+// BrowserFamilies returns the sorted families of the clients.
+func (c *client) BrowserFamilies() []string {
+	return c.data.Load().BrowserFamilies()
+}
+
+// OSFamilies returns the sorted families of the operating systems.
+func (c *client) OSFamilies() []string {
+	return c.data.Load().OSFamilies()
+}
+
+// CrawlerFamilies returns the sorted families of the crawlers.
+func (c *client) CrawlerFamilies() []string {
+	return c.data.Load().CrawlerFamilies()
+}
+
+// DataCenterPrefixes returns the ranges of the datacenter as sorted CIDR prefixes.
+func (c *client) DataCenterPrefixes(id int) []netip.Prefix {
+	return c.data.Load().DataCenterPrefixes(id)
+}
+
+// DataCentersOverlapping returns the datacenters with at least one range overlapping the prefix.
+func (c *client) DataCentersOverlapping(prefix netip.Prefix) []DataCenter {
+	return c.data.Load().DataCentersOverlapping(prefix)
+}
+
+// DataCenterCIDRs returns the ranges of all the datacenters as the smallest sorted list of CIDR prefixes,
+// IPv4 first, e.g. to configure a firewall.
+func (c *client) DataCenterCIDRs() []netip.Prefix {
+	return c.data.Load().DataCenterCIDRs()
+}
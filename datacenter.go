@@ -0,0 +1,175 @@
+package udger
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// DataCenterIndex is implemented by the clients returned by New, NewFromDB and NewFromSnapshot.
+type DataCenterIndex interface {
+	// DataCenterPrefixes returns the ranges of the datacenter as sorted CIDR prefixes
+	DataCenterPrefixes(id int) []netip.Prefix
+	// DataCentersOverlapping returns the datacenters with at least one range overlapping the prefix
+	DataCentersOverlapping(prefix netip.Prefix) []DataCenter
+	// DataCenterCIDRs returns the ranges of all the datacenters as the smallest sorted list of CIDR prefixes
+	DataCenterCIDRs() []netip.Prefix
+}
+
+// addrRange is an inclusive range of addresses of the same family.
+type addrRange struct {
+	from netip.Addr
+	to   netip.Addr
+}
+
+func (r dcRange4) addrRange() addrRange {
+	return addrRange{from: addrFrom4(r.From), to: addrFrom4(r.To)}
+}
+
+func (r dcRange6) addrRange() addrRange {
+	return addrRange{from: netip.AddrFrom16(r.From), to: netip.AddrFrom16(r.To)}
+}
+
+// DataCenterPrefixes returns the ranges of the datacenter as sorted CIDR prefixes.
+func (u *udger) DataCenterPrefixes(id int) []netip.Prefix {
+	return rangesPrefixes(u.dataCenterRanges(func(dcID int32) bool { return int(dcID) == id }))
+}
+
+// DataCentersOverlapping returns the datacenters with at least one range overlapping the prefix.
+func (u *udger) DataCentersOverlapping(prefix netip.Prefix) []DataCenter {
+	if !prefix.IsValid() {
+		return nil
+	}
+	prefix = canonicalPrefix(prefix)
+	pr := addrRange{from: prefix.Addr(), to: lastAddr(prefix)}
+
+	seen := make(map[int32]bool)
+	var ids []int
+	add := func(dcID int32, r addrRange) {
+		if !seen[dcID] && r.from.Compare(pr.to) <= 0 && pr.from.Compare(r.to) <= 0 {
+			seen[dcID] = true
+			ids = append(ids, int(dcID))
+		}
+	}
+	if prefix.Addr().Is4() {
+		for _, r := range u.dcRanges4 {
+			add(r.DataCenterID, r.addrRange())
+		}
+	} else {
+		for _, r := range u.dcRanges6 {
+			add(r.DataCenterID, r.addrRange())
+		}
+	}
+
+	sort.Ints(ids)
+	dcs := make([]DataCenter, 0, len(ids))
+	for _, id := range ids {
+		dc, ok := u.dataCenters[id]
+		if !ok {
+			dc = DataCenter{ID: id}
+		}
+		dcs = append(dcs, dc)
+	}
+
+	return dcs
+}
+
+// DataCenterCIDRs returns the ranges of all the datacenters as the smallest sorted list of CIDR prefixes,
+// IPv4 first, e.g. to configure a firewall.
+func (u *udger) DataCenterCIDRs() []netip.Prefix {
+	return rangesPrefixes(u.dataCenterRanges(func(int32) bool { return true }))
+}
+
+func (u *udger) dataCenterRanges(match func(dcID int32) bool) []addrRange {
+	var ranges []addrRange
+	for _, r := range u.dcRanges4 {
+		if match(r.DataCenterID) {
+			ranges = append(ranges, r.addrRange())
+		}
+	}
+	for _, r := range u.dcRanges6 {
+		if match(r.DataCenterID) {
+			ranges = append(ranges, r.addrRange())
+		}
+	}
+
+	return ranges
+}
+
+// rangesPrefixes merges the overlapping and adjacent ranges and returns them as CIDR prefixes.
+func rangesPrefixes(ranges []addrRange) []netip.Prefix {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].from.Less(ranges[j].from)
+	})
+
+	var merged []addrRange
+	for _, r := range ranges {
+		if r.to.Less(r.from) {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].from.BitLen() == r.from.BitLen() {
+			last := &merged[n-1]
+			next := last.to.Next()
+			if !next.IsValid() || r.from.Compare(next) <= 0 {
+				if last.to.Less(r.to) {
+					last.to = r.to
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+
+	var prefixes []netip.Prefix
+	for _, r := range merged {
+		prefixes = append(prefixes, rangePrefixes(r.from, r.to)...)
+	}
+
+	return prefixes
+}
+
+// rangePrefixes splits an inclusive range of addresses into the smallest list of CIDR prefixes.
+func rangePrefixes(from, to netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for from.Compare(to) <= 0 {
+		var p netip.Prefix
+		for bits := 0; bits <= from.BitLen(); bits++ {
+			p = netip.PrefixFrom(from, bits).Masked()
+			if p.Addr() == from && lastAddr(p).Compare(to) <= 0 {
+				break
+			}
+		}
+		prefixes = append(prefixes, p)
+
+		from = lastAddr(p).Next()
+		if !from.IsValid() {
+			break
+		}
+	}
+
+	return prefixes
+}
+
+// lastAddr returns the last address of the prefix.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().As16()
+	offset := 128 - p.Addr().BitLen()
+	for i := offset + p.Bits(); i < 128; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+
+	addr := netip.AddrFrom16(b)
+	if p.Addr().Is4() {
+		return addr.Unmap()
+	}
+
+	return addr
+}
+
+// canonicalPrefix converts IPv4-mapped IPv6 prefixes to IPv4 prefixes.
+func canonicalPrefix(p netip.Prefix) netip.Prefix {
+	if p.Addr().Is4In6() && p.Bits() >= 96 {
+		return netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96).Masked()
+	}
+
+	return netip.PrefixFrom(p.Addr().WithZone(""), p.Bits()).Masked()
+}
@@ -0,0 +1,84 @@
+package udger
+
+import (
+	"net/netip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func prefixes(ss ...string) []netip.Prefix {
+	out := make([]netip.Prefix, len(ss))
+	for i, s := range ss {
+		out[i] = netip.MustParsePrefix(s)
+	}
+
+	return out
+}
+
+func TestRangePrefixes(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     []netip.Prefix
+	}{
+		{from: "66.249.64.0", to: "66.249.95.255", want: prefixes("66.249.64.0/19")},
+		{from: "10.0.0.1", to: "10.0.0.1", want: prefixes("10.0.0.1/32")},
+		{from: "10.0.0.1", to: "10.0.0.10", want: prefixes("10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/31", "10.0.0.10/32")},
+		{from: "0.0.0.0", to: "255.255.255.255", want: prefixes("0.0.0.0/0")},
+		{from: "255.255.255.254", to: "255.255.255.255", want: prefixes("255.255.255.254/31")},
+		{from: "2001:4860::", to: "2001:4860:ffff:ffff:ffff:ffff:ffff:ffff", want: prefixes("2001:4860::/32")},
+		{from: "2001:db8::", to: "2001:db8::2", want: prefixes("2001:db8::/127", "2001:db8::2/128")},
+		{from: "10.0.0.2", to: "10.0.0.1", want: nil},
+	}
+
+	Convey("split ranges into prefixes", t, func() {
+		for _, test := range tests {
+			got := rangePrefixes(netip.MustParseAddr(test.from), netip.MustParseAddr(test.to))
+			So(got, ShouldResemble, test.want)
+		}
+	})
+}
+
+func TestDataCenterIndex(t *testing.T) {
+	Convey("query the datacenter ranges", t, func() {
+		u := testUdger()
+		u.dataCenters[6] = DataCenter{ID: 6, Name: "Amazon AWS", NameCode: "amazon_aws"}
+		u.dcRanges4 = append(u.dcRanges4,
+			dcRange4{From: 0x0A000000, To: 0x0A0000FF, DataCenterID: 6},
+			dcRange4{From: 0x0A000100, To: 0x0A0001FF, DataCenterID: 6},
+			dcRange4{From: 0x0A000080, To: 0x0A00017F, DataCenterID: 5},
+		)
+		u.dcRanges6 = append(u.dcRanges6, newDCRange6(DataCenterRange6{DatacenterID: 5, IPLongFrom0: 0x2001, IPLongFrom1: 0x4860, IPLongTo0: 0x2001, IPLongTo1: 0x4860, IPLongTo2: 0xffff, IPLongTo3: 0xffff, IPLongTo4: 0xffff, IPLongTo5: 0xffff, IPLongTo6: 0xffff, IPLongTo7: 0xffff}))
+
+		var _ DataCenterIndex = u
+
+		Convey("list the prefixes of a datacenter", func() {
+			So(u.DataCenterPrefixes(6), ShouldResemble, prefixes("10.0.0.0/23"))
+			So(u.DataCenterPrefixes(5), ShouldResemble, prefixes("10.0.0.128/25", "10.0.1.0/25", "66.249.64.0/19", "2001:4860::/32"))
+			So(u.DataCenterPrefixes(7), ShouldBeEmpty)
+		})
+
+		Convey("find the datacenters overlapping a prefix", func() {
+			dcs := u.DataCentersOverlapping(netip.MustParsePrefix("10.0.0.0/24"))
+			So(dcs, ShouldHaveLength, 2)
+			So(dcs[0].NameCode, ShouldEqual, "google")
+			So(dcs[1].NameCode, ShouldEqual, "amazon_aws")
+
+			dcs = u.DataCentersOverlapping(netip.MustParsePrefix("10.0.1.255/32"))
+			So(dcs, ShouldHaveLength, 1)
+			So(dcs[0].NameCode, ShouldEqual, "amazon_aws")
+
+			dcs = u.DataCentersOverlapping(netip.MustParsePrefix("::ffff:66.249.70.0/120"))
+			So(dcs, ShouldHaveLength, 1)
+			So(dcs[0].NameCode, ShouldEqual, "google")
+
+			So(u.DataCentersOverlapping(netip.MustParsePrefix("2001::/16")), ShouldHaveLength, 1)
+			So(u.DataCentersOverlapping(netip.MustParsePrefix("192.168.0.0/16")), ShouldBeEmpty)
+			So(u.DataCentersOverlapping(netip.Prefix{}), ShouldBeEmpty)
+		})
+
+		Convey("export all the ranges", func() {
+			So(u.DataCenterCIDRs(), ShouldResemble, prefixes("10.0.0.0/23", "66.249.64.0/19", "2001:4860::/32"))
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package udger_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewFromDB(t *testing.T) {
+	Convey("load from a caller owned handle", t, func() {
+		db, err := sql.Open(udgertest.Driver, createTestDB(t))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		u, err := udger.NewFromDB(db)
+		So(err, ShouldBeNil)
+		So(u, ShouldNotBeNil)
+		So(db.Ping(), ShouldBeNil)
+
+		info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		So(info.Browser.Family, ShouldEqual, "IE")
+	})
+
+	Convey("load tables imported under a schema", t, func() {
+		db, err := sql.Open(udgertest.Driver, ":memory:")
+		So(err, ShouldBeNil)
+		defer db.Close()
+		db.SetMaxOpenConns(1)
+
+		_, err = db.Exec("ATTACH DATABASE ? AS ua", createTestDB(t))
+		So(err, ShouldBeNil)
+
+		u, err := udger.NewFromDB(db, udger.WithTablePrefix("ua."))
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		So(info.OS.Name, ShouldEqual, "Windows 7")
+	})
+
+	Convey("load a renamed table", t, func() {
+		db, err := sql.Open(udgertest.Driver, createTestDB(t))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		_, err = db.Exec("ALTER TABLE udger_os_list RENAME TO operating_systems")
+		So(err, ShouldBeNil)
+
+		_, err = udger.NewFromDB(db)
+		So(err, ShouldNotBeNil)
+
+		u, err := udger.NewFromDB(db, udger.WithTableName("udger_os_list", "operating_systems"))
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		So(info.OS.Name, ShouldEqual, "Windows 7")
+	})
+}
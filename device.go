@@ -0,0 +1,80 @@
+package udger
+
+// DeviceSource tells how the device of an Info was determined.
+type DeviceSource string
+
+// Device sources, in the order they are tried.
+const (
+	// DeviceSourceRegex is a match of a regex of udger_deviceclass_regex
+	DeviceSourceRegex DeviceSource = "regex"
+	// DeviceSourceClientClass is the device class of the client class, from udger_client_class
+	DeviceSourceClientClass DeviceSource = "client_class"
+	// DeviceSourceOS is the device class hinted by an operating system dedicated to a kind of device
+	DeviceSourceOS DeviceSource = "os"
+	// DeviceSourceGuess is a guess from the client class code, a personal computer by default
+	DeviceSourceGuess DeviceSource = "guess"
+)
+
+// osDeviceHints are the device classes of the operating system families dedicated to a kind of device,
+// keyed by the family_code of udger_os_list.
+var osDeviceHints = map[string]DeviceClass{
+	"android":       DeviceClassSmartphone,
+	"ios":           DeviceClassSmartphone,
+	"windows_phone": DeviceClassSmartphone,
+	"blackberry_os": DeviceClassSmartphone,
+	"symbian":       DeviceClassSmartphone,
+	"nintendo":      DeviceClassGameConsole,
+	"playstation":   DeviceClassGameConsole,
+}
+
+// The devices guessed from the client class when no device regex matches.
+var (
+	smartphoneDevice = Device{Name: "Smartphone", Class: DeviceClassSmartphone, Icon: "phone.png"}
+	otherDevice      = Device{Name: "Other", Class: DeviceClassOther, Icon: "other.png"}
+	desktopDevice    = Device{Name: "Personal computer", Class: DeviceClassDesktop, Icon: "desktop.png"}
+)
+
+// guessDevice returns the device of a client of the given class whose device is not detected.
+func guessDevice(class ClientClass) Device {
+	switch class {
+	case ClientClassMobileBrowser:
+		return smartphoneDevice
+	case ClientClassLibrary, ClientClassMultimediaPlayer, ClientClassValidator, ClientClassOther, ClientClassCrawler:
+		return otherDevice
+	default:
+		return desktopDevice
+	}
+}
+
+// fallbackDevice returns the device of a client when no device regex matches, like the reference
+// parsers: the device class of its client class, then the device class hinted by its operating system,
+// then a guess from its client class code.
+func (u *udger) fallbackDevice(info *Info) (Device, DeviceSource) {
+	if id, ok := u.classDevices[info.Browser.typ]; ok {
+		if d, ok := u.devices[id]; ok {
+			return d, DeviceSourceClientClass
+		}
+	}
+
+	if class, ok := osDeviceHints[info.OS.FamilyCode]; ok {
+		if d, ok := u.deviceOfClass(class); ok {
+			return d, DeviceSourceOS
+		}
+	}
+
+	return guessDevice(info.Browser.Class), DeviceSourceGuess
+}
+
+// deviceOfClass returns the device class of the database with the given code,
+// the one with the lowest ID when several share the code.
+func (u *udger) deviceOfClass(class DeviceClass) (Device, bool) {
+	var found Device
+	ok := false
+	for _, d := range u.devices {
+		if d.Class == class && (!ok || d.ID < found.ID) {
+			found, ok = d, true
+		}
+	}
+
+	return found, ok
+}
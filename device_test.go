@@ -0,0 +1,33 @@
+package udger
+
+import (
+	"os"
+	"testing"
+)
+
+// TestOSDeviceHints checks the family codes of the hints against the udger database, e.g.
+//
+// UDGER_DB=udgerdb_v3.dat go test -run TestOSDeviceHints -v
+func TestOSDeviceHints(t *testing.T) {
+	dbPath := os.Getenv("UDGER_DB")
+	if dbPath == "" {
+		t.Skip("UDGER_DB is not set")
+	}
+
+	c, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := c.(*client).acquire()
+	defer u.release()
+
+	families := make(map[string]bool)
+	for _, o := range u.os {
+		families[o.FamilyCode] = true
+	}
+	for code, class := range osDeviceHints {
+		if !families[code] {
+			t.Errorf("the hint of %s is for the unknown os family %q", class, code)
+		}
+	}
+}
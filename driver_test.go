@@ -0,0 +1,36 @@
+package udger_test
+
+import (
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// createTestDB creates the default fixture database of udgertest.
+func createTestDB(t *testing.T) string {
+	t.Helper()
+
+	return udgertest.Create(t, udgertest.Default())
+}
+
+func TestPureGoDriver(t *testing.T) {
+	Convey("load with the pure go driver", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+		So(u, ShouldNotBeNil)
+
+		info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		So(info.Browser.Family, ShouldEqual, "IE")
+		So(info.Browser.Type, ShouldEqual, "Browser")
+		So(info.OS.Name, ShouldEqual, "Windows 7")
+	})
+
+	Convey("load with an unknown driver", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver("unknown"))
+		So(err, ShouldNotBeNil)
+		So(u, ShouldBeNil)
+	})
+}
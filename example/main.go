@@ -18,6 +18,7 @@ func main() {
 		fmt.Println("error: ", err)
 		os.Exit(-1)
 	}
+	defer u.Close()
 
 	ua, err := u.Lookup(os.Args[2])
 	if err != nil {
@@ -0,0 +1,62 @@
+package udger
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzCleanRegex(f *testing.F) {
+	for _, s := range []string{`/msie ([0-9a-z\._]+)/si`, `/si`, `/`, ``, `//si`, `chrome/si`, `/(?:iphone|ipad).*mobile\//si`} {
+		f.Add(s, "Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1)")
+	}
+
+	u := testUdger()
+	f.Fuzz(func(t *testing.T, regex, ua string) {
+		cleaned := u.cleanRegex(regex)
+		if !strings.Contains(regex, cleaned) || len(regex)-len(cleaned) > 4 {
+			t.Fatalf("cleanRegex(%q) = %q", regex, cleaned)
+		}
+
+		d := rexData{ID: 1, Regex: cleaned}
+		if err := d.compile(); err != nil {
+			return
+		}
+		// findData is called without the recover of findDataWithVersion
+		id, version, err := u.findData(ua, []rexData{d}, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 && (id != -1 || version != "") {
+			t.Fatalf("unexpected match %d %q", id, version)
+		}
+		if !utf8.ValidString(version) {
+			t.Fatalf("version is not valid UTF-8: %q", version)
+		}
+	})
+}
+
+func FuzzDataCenterRange6(f *testing.F) {
+	f.Add([]byte(netip.MustParseAddr("2001:4860::").AsSlice()), []byte(netip.MustParseAddr("2001:4860:ffff:ffff:ffff:ffff:ffff:ffff").AsSlice()), []byte(netip.MustParseAddr("2001:4860::1").AsSlice()))
+	f.Add(make([]byte, 16), bytes.Repeat([]byte{0xff}, 16), bytes.Repeat([]byte{0x80}, 16))
+
+	f.Fuzz(func(t *testing.T, from, to, ip []byte) {
+		if len(from) != 16 || len(to) != 16 || len(ip) != 16 {
+			return
+		}
+		var r dcRange6
+		copy(r.From[:], from)
+		copy(r.To[:], to)
+		addr := netip.AddrFrom16(*(*[16]byte)(ip))
+
+		want := netip.AddrFrom16(r.From).Compare(addr) <= 0 && addr.Compare(netip.AddrFrom16(r.To)) <= 0
+		if got := r.contains(addr.As16()); got != want {
+			t.Fatalf("%v in [%v, %v]: got %v, want %v", addr, netip.AddrFrom16(r.From), netip.AddrFrom16(r.To), got, want)
+		}
+		if back := newDCRange6(r.expand()); back != r {
+			t.Fatalf("expand is not reversible: %+v != %+v", back, r)
+		}
+	})
+}
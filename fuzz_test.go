@@ -0,0 +1,112 @@
+package udger_test
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+)
+
+func fuzzClient(f *testing.F) udger.Client {
+	f.Helper()
+
+	d := udgertest.Default()
+	d.DataCenterRanges = append(d.DataCenterRanges,
+		udgertest.DataCenterRange{DataCenterID: 5, From: "::", To: "::ffff"},
+		udgertest.DataCenterRange{DataCenterID: 5, From: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:0", To: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+		udgertest.DataCenterRange{DataCenterID: 5, From: "0.0.0.0", To: "0.0.0.255"},
+	)
+	u, err := udger.New(udgertest.Create(f, d), udger.WithDriver(udgertest.Driver))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return u
+}
+
+// checkUTF8 fails when a string of v is not valid UTF-8.
+func checkUTF8(t *testing.T, v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.String:
+		if !utf8.ValidString(v.String()) {
+			t.Errorf("%s is not valid UTF-8: %q", path, v.String())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				checkUTF8(t, v.Field(i), path+"."+v.Type().Field(i).Name)
+			}
+		}
+	}
+}
+
+func FuzzLookup(f *testing.F) {
+	u := fuzzClient(f)
+	cases, err := udgertest.LoadCorpus(goldenPath)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, c := range cases {
+		f.Add(c.Test.TestString)
+	}
+	f.Add("Chrome/\xff\xfe")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, ua string) {
+		info, err := u.Lookup(ua)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := u.Lookup(ua)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(info, again) {
+			t.Fatalf("non deterministic lookup: %+v != %+v", info, again)
+		}
+		checkUTF8(t, reflect.ValueOf(*info), "Info")
+	})
+}
+
+func FuzzLookupIP(f *testing.F) {
+	u := fuzzClient(f)
+	for _, ip := range []string{"66.249.64.1", "66.249.95.255", "2001:4860::", "2001:4860:ffff:ffff:ffff:ffff:ffff:ffff", "::ffff:66.249.64.1", "::1", "10.0.0.1"} {
+		f.Add([]byte(net.ParseIP(ip)))
+	}
+	f.Add([]byte{})
+	f.Add([]byte{1, 2, 3})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		info, err := u.LookupIP(net.IP(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := u.LookupIP(net.IP(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(info, again) {
+			t.Fatalf("non deterministic lookup: %+v != %+v", info, again)
+		}
+		checkUTF8(t, reflect.ValueOf(*info), "IPInfo")
+
+		addr, ok := netip.AddrFromSlice(b)
+		if !ok {
+			if !reflect.DeepEqual(info, &udger.IPInfo{}) {
+				t.Fatalf("invalid IP %v matched %+v", b, info)
+			}
+			return
+		}
+		byAddr, err := u.LookupAddr(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(info, byAddr) {
+			t.Fatalf("LookupIP and LookupAddr differ: %+v != %+v", info, byAddr)
+		}
+	})
+}
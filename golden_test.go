@@ -0,0 +1,71 @@
+package udger_test
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// update regenerates the golden files: go test -run TestGolden -update
+var update = flag.Bool("update", false, "regenerate the golden files")
+
+const goldenPath = "testdata/golden.json"
+
+// TestGolden is a self regression test: the golden file is generated by this package on the
+// fixture database, it catches the changes of the results, not the differences with the official
+// parsers, see TestCorpus.
+func TestGolden(t *testing.T) {
+	u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := udgertest.LoadCorpus(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if cases, err = udgertest.Generate(u, cases); err != nil {
+			t.Fatal(err)
+		}
+		if err := udgertest.WriteCorpus(goldenPath, cases); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Convey("match the golden file", t, func() {
+		report, err := udgertest.Compare(u, cases)
+		So(err, ShouldBeNil)
+		So(report.Diffs, ShouldBeEmpty)
+		So(report.Unsupported, ShouldBeEmpty)
+		So(report.Accuracy(), ShouldEqual, 100)
+	})
+}
+
+// TestCorpus compares the lookups with the output of the official parsers on the licensed database:
+// UDGER_DB=udgerdb_v3.dat UDGER_CORPUS=test_ua.json go test -run TestCorpus -v
+func TestCorpus(t *testing.T) {
+	dbPath, corpusPath := os.Getenv("UDGER_DB"), os.Getenv("UDGER_CORPUS")
+	if dbPath == "" || corpusPath == "" {
+		t.Skip("UDGER_DB and UDGER_CORPUS are not set")
+	}
+
+	u, err := udger.New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases, err := udgertest.LoadCorpus(corpusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := udgertest.Compare(u, cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(report)
+}
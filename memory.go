@@ -0,0 +1,239 @@
+package udger
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"reflect"
+)
+
+// MemoryReporter is implemented by the clients returned by New, NewFromDB and NewFromSnapshot.
+type MemoryReporter interface {
+	// MemoryStats reports the number of entries and the approximate memory used by each table loaded in memory
+	MemoryStats() MemoryStats
+}
+
+// MemoryStats contains the memory usage of the tables loaded in memory.
+type MemoryStats struct {
+	Tables []TableStats `json:"tables"`
+}
+
+// TableStats contains the memory usage of one table. Bytes counts the fixed size of the entries
+// and the string data shared between entries once, the allocator and map overhead is not included.
+// Skipped counts the rows of the database not loaded because they are invalid.
+type TableStats struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"`
+	Skipped int    `json:"skipped,omitempty"`
+}
+
+// TotalBytes returns the approximate memory used by all the tables.
+func (s MemoryStats) TotalBytes() int {
+	var total int
+	for _, t := range s.Tables {
+		total += t.Bytes
+	}
+
+	return total
+}
+
+// MemoryStats reports the number of entries and the approximate memory used by each table loaded in memory.
+func (u *udger) MemoryStats() MemoryStats {
+	c := stringCounter{seen: make(map[string]struct{})}
+	ips := mapStats("udger_ip_list", u.ips, &c)
+	ips.Skipped = u.skippedIPs
+
+	return MemoryStats{Tables: []TableStats{
+		sliceStats("udger_client_regex", u.rexBrowsers, &c),
+		sliceStats("udger_deviceclass_regex", u.rexDevices, &c),
+		sliceStats("udger_os_regex", u.rexOS, &c),
+		mapStats("udger_client_list", u.browsers, &c),
+		mapStats("udger_os_list", u.os, &c),
+		mapStats("udger_deviceclass_list", u.devices, &c),
+		mapStats("udger_client_class", u.browserTypes, &c),
+		mapStats("udger_client_class.client_classification_code", u.browserClasses, &c),
+		mapStats("udger_client_class.deviceclass_id", u.classDevices, &c),
+		mapStats("udger_client_os_relation", u.browserOS, &c),
+		ips,
+		mapStats("udger_ip_class", u.ipClasses, &c),
+		mapStats("udger_crawler_list", u.crawlers, &c),
+		mapStats("udger_crawler_list.ua_string", u.crawlerUAs, &c),
+		mapStats("udger_crawler_class", u.crawlerClasses, &c),
+		mapStats("udger_datacenter_list", u.dataCenters, &c),
+		sliceStats("udger_datacenter_range", u.dcRanges4, &c),
+		sliceStats("udger_datacenter_range6", u.dcRanges6, &c),
+		u.names.stats(&c),
+	}}
+}
+
+func mapStats[K comparable, V any](name string, m map[K]V, c *stringCounter) TableStats {
+	size := int(reflect.TypeOf((*K)(nil)).Elem().Size() + reflect.TypeOf((*V)(nil)).Elem().Size())
+	t := TableStats{Name: name, Entries: len(m), Bytes: len(m) * size}
+	for k, v := range m {
+		t.Bytes += c.count(reflect.ValueOf(k)) + c.count(reflect.ValueOf(v))
+	}
+
+	return t
+}
+
+func sliceStats[V any](name string, s []V, c *stringCounter) TableStats {
+	size := int(reflect.TypeOf((*V)(nil)).Elem().Size())
+	t := TableStats{Name: name, Entries: len(s), Bytes: cap(s) * size}
+	for _, v := range s {
+		t.Bytes += c.count(reflect.ValueOf(v))
+	}
+
+	return t
+}
+
+// stringCounter counts the bytes of the strings held by a value, counting every distinct string once.
+type stringCounter struct {
+	seen map[string]struct{}
+}
+
+func (c *stringCounter) count(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if _, ok := c.seen[s]; ok {
+			return 0
+		}
+		c.seen[s] = struct{}{}
+		return len(s)
+	case reflect.Struct:
+		var n int
+		for i := 0; i < v.NumField(); i++ {
+			n += c.count(v.Field(i))
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+// interner deduplicates the strings repeated across the rows of a table while loading.
+type interner map[string]string
+
+func (in interner) intern(s string) string {
+	if v, ok := in[s]; ok {
+		return v
+	}
+	in[s] = s
+
+	return s
+}
+
+func (in interner) ip(ip *IP) {
+	ip.IPLastSeen = in.intern(ip.IPLastSeen)
+	ip.IPCountry = in.intern(ip.IPCountry)
+	ip.IPCity = in.intern(ip.IPCity)
+	ip.IPCountryCode = in.intern(ip.IPCountryCode)
+}
+
+func (in interner) crawler(c *Crawler) {
+	c.Ver = in.intern(c.Ver)
+	c.VerMajor = in.intern(c.VerMajor)
+	c.LastSeen = in.intern(c.LastSeen)
+	c.RespectRobotstxt = in.intern(c.RespectRobotstxt)
+	c.Family = in.intern(c.Family)
+	c.FamilyCode = in.intern(c.FamilyCode)
+	c.FamilyHomepage = in.intern(c.FamilyHomepage)
+	c.FamilyIcon = in.intern(c.FamilyIcon)
+	c.Vendor = in.intern(c.Vendor)
+	c.VendorCode = in.intern(c.VendorCode)
+	c.VendorHomepage = in.intern(c.VendorHomepage)
+}
+
+// dcRange4 is the compact form of a row of udger_datacenter_range.
+type dcRange4 struct {
+	From         uint32
+	To           uint32
+	DataCenterID int32
+}
+
+func newDCRange4(d DataCenterRange) dcRange4 {
+	return dcRange4{
+		From:         uint32(d.IPLongFrom),
+		To:           uint32(d.IPLongTo),
+		DataCenterID: int32(d.DatacenterID),
+	}
+}
+
+func (r dcRange4) contains(ip uint32) bool {
+	return ip >= r.From && ip <= r.To
+}
+
+func (r dcRange4) expand() DataCenterRange {
+	return DataCenterRange{
+		DatacenterID: int(r.DataCenterID),
+		IPFrom:       addrFrom4(r.From).String(),
+		IPTo:         addrFrom4(r.To).String(),
+		IPLongFrom:   int(r.From),
+		IPLongTo:     int(r.To),
+	}
+}
+
+func addrFrom4(ip uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], ip)
+
+	return netip.AddrFrom4(b)
+}
+
+// dcRange6 is the compact form of a row of udger_datacenter_range6.
+type dcRange6 struct {
+	From         [16]byte
+	To           [16]byte
+	DataCenterID int32
+}
+
+func newDCRange6(d DataCenterRange6) dcRange6 {
+	r := dcRange6{DataCenterID: int32(d.DatacenterID)}
+	from, to := d.groups()
+	for i := 0; i < 8; i++ {
+		binary.BigEndian.PutUint16(r.From[2*i:], uint16(*from[i]))
+		binary.BigEndian.PutUint16(r.To[2*i:], uint16(*to[i]))
+	}
+
+	return r
+}
+
+func (r dcRange6) contains(ip [16]byte) bool {
+	return compare16(ip, r.From) >= 0 && compare16(ip, r.To) <= 0
+}
+
+func (r dcRange6) expand() DataCenterRange6 {
+	d := DataCenterRange6{
+		DatacenterID: int(r.DataCenterID),
+		IPFrom:       netip.AddrFrom16(r.From).String(),
+		IPTo:         netip.AddrFrom16(r.To).String(),
+	}
+	from, to := d.groups()
+	for i := 0; i < 8; i++ {
+		*from[i] = int(binary.BigEndian.Uint16(r.From[2*i:]))
+		*to[i] = int(binary.BigEndian.Uint16(r.To[2*i:]))
+	}
+
+	return d
+}
+
+// groups returns the 16 bits groups of the range bounds, most significant first.
+func (d *DataCenterRange6) groups() (from, to [8]*int) {
+	from = [8]*int{&d.IPLongFrom0, &d.IPLongFrom1, &d.IPLongFrom2, &d.IPLongFrom3, &d.IPLongFrom4, &d.IPLongFrom5, &d.IPLongFrom6, &d.IPLongFrom7}
+	to = [8]*int{&d.IPLongTo0, &d.IPLongTo1, &d.IPLongTo2, &d.IPLongTo3, &d.IPLongTo4, &d.IPLongTo5, &d.IPLongTo6, &d.IPLongTo7}
+
+	return from, to
+}
+
+func compare16(a, b [16]byte) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
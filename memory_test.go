@@ -0,0 +1,170 @@
+package udger_test
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryStats(t *testing.T) {
+	Convey("report the memory used by the tables", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		r, ok := u.(udger.MemoryReporter)
+		So(ok, ShouldBeTrue)
+
+		stats := r.MemoryStats()
+		entries := make(map[string]int)
+		for _, table := range stats.Tables {
+			entries[table.Name] = table.Entries
+		}
+		So(entries["udger_client_regex"], ShouldEqual, 4)
+		So(entries["udger_ip_list"], ShouldEqual, 1)
+		So(entries["udger_crawler_list"], ShouldEqual, 2)
+		So(entries["udger_datacenter_range"], ShouldEqual, 1)
+		So(entries["udger_datacenter_range6"], ShouldEqual, 1)
+		So(entries["udger_client_class.client_classification_code"], ShouldEqual, entries["udger_client_class"])
+		So(entries, ShouldContainKey, "udger_client_class.deviceclass_id")
+		So(entries["browser names"], ShouldEqual, 0)
+		So(stats.TotalBytes(), ShouldBeGreaterThan, 0)
+
+		_, err = u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		for _, table := range r.MemoryStats().Tables {
+			if table.Name == "browser names" {
+				So(table.Entries, ShouldEqual, 1)
+			}
+		}
+	})
+
+	Convey("count the invalid IP rows", t, func() {
+		d := udgertest.Default()
+		d.IPs = append(d.IPs, udger.IP{IP: "not an ip", ClassID: 1}, udger.IP{IP: "66.249.64.300", ClassID: 1})
+		u, err := udger.New(udgertest.Create(t, d), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		check := func(stats udger.MemoryStats) {
+			for _, table := range stats.Tables {
+				if table.Name == "udger_ip_list" {
+					So(table.Entries, ShouldEqual, 1)
+					So(table.Skipped, ShouldEqual, 2)
+				}
+			}
+		}
+		check(u.(udger.MemoryReporter).MemoryStats())
+
+		Convey("kept by the snapshots", func() {
+			var buf bytes.Buffer
+			So(udger.WriteSnapshot(&buf, u), ShouldBeNil)
+			snap, err := udger.NewFromSnapshot(&buf)
+			So(err, ShouldBeNil)
+			check(snap.(udger.MemoryReporter).MemoryStats())
+		})
+	})
+
+	Convey("the compact ranges still match", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		info, err := u.LookupIP(net.ParseIP("66.249.70.1"))
+		So(err, ShouldBeNil)
+		So(info.DataCenter.NameCode, ShouldEqual, "google")
+		So(info.DataCenterRange, ShouldResemble, udger.DataCenterRange{DatacenterID: 5, IPFrom: "66.249.64.0", IPTo: "66.249.95.255", IPLongFrom: 1123631104, IPLongTo: 1123639295})
+
+		info, err = u.LookupIP(net.ParseIP("2001:4860:4801:10::1"))
+		So(err, ShouldBeNil)
+		So(info.DataCenter.NameCode, ShouldEqual, "google")
+		So(info.DataCenterRange6.IPFrom, ShouldEqual, "2001:4860::")
+		So(info.DataCenterRange6.IPLongFrom1, ShouldEqual, 18528)
+		So(info.DataCenterRange6.IPLongTo7, ShouldEqual, 65535)
+
+		info, err = u.LookupIP(net.ParseIP("2001:4861::1"))
+		So(err, ShouldBeNil)
+		So(info.DataCenter, ShouldResemble, udger.DataCenter{})
+	})
+}
+
+// heapDB returns the default database with n IPs and n/100 crawlers sharing a few countries
+// and families, and n/10 datacenter ranges, the tables whose representation is compacted.
+func heapDB(n int) udgertest.DB {
+	d := udgertest.Default()
+	places := []struct{ country, code, city string }{
+		{"United States", "US", "Mountain View"},
+		{"Germany", "DE", "Frankfurt am Main"},
+		{"Ireland", "IE", "Dublin"},
+		{"Singapore", "SG", "Singapore"},
+	}
+	for i := 0; i < n/100; i++ {
+		d.Crawlers = append(d.Crawlers, udger.Crawler{
+			ID: 100 + i, UA: fmt.Sprintf("Examplebot/%d.0", i), Ver: fmt.Sprintf("%d.0", i), ClassID: 4, LastSeen: "2016-01-01",
+			RespectRobotstxt: "yes", Family: "Examplebot", FamilyCode: "examplebot", Vendor: "Example Inc.", VendorCode: "example_inc",
+			Name: fmt.Sprintf("Examplebot/%d.0", i),
+		})
+	}
+	for i := 0; i < n; i++ {
+		p := places[i%len(places)]
+		d.IPs = append(d.IPs, udger.IP{
+			IP: fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255), ClassID: 1, CrawlerID: 100 + i%(n/100),
+			IPLastSeen: "2016-01-01", IPHostname: fmt.Sprintf("crawl-%d.example.com", i), IPCountry: p.country, IPCity: p.city, IPCountryCode: p.code,
+		})
+	}
+	for i := 0; i < n/10; i++ {
+		d.DataCenterRanges = append(d.DataCenterRanges, udgertest.DataCenterRange{
+			DataCenterID: 5, From: fmt.Sprintf("11.%d.%d.0", i>>8&255, i&255), To: fmt.Sprintf("11.%d.%d.255", i>>8&255, i&255),
+		})
+	}
+
+	return d
+}
+
+// fixtureHeapBaseline is the heap retained by a client loaded from heapDB(50000) before the tables
+// were compacted, with the IPs keyed by string and the datacenter ranges stored as loaded.
+const fixtureHeapBaseline = 14308888
+
+// BenchmarkNewHeap reports the heap retained by a client loaded from a generated database,
+// compared to the recorded baseline, and from the full v3 database when available.
+func BenchmarkNewHeap(b *testing.B) {
+	b.Run("fixture", func(b *testing.B) {
+		benchmarkHeap(b, fixtureHeapBaseline, udgertest.Create(b, heapDB(50000)), udger.WithDriver(udgertest.Driver))
+	})
+
+	b.Run("v3", func(b *testing.B) {
+		if _, err := os.Stat("./udgerdb_v3.dat"); err != nil {
+			b.Skip("the udger v3 database is not available")
+		}
+		benchmarkHeap(b, 0, "./udgerdb_v3.dat")
+	})
+}
+
+// benchmarkHeap reports the heap retained by New, and its change from baseline when not 0.
+func benchmarkHeap(b *testing.B, baseline int64, path string, opts ...udger.Option) {
+	var before, after runtime.MemStats
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		u, err := udger.New(path, opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		// signed, the heap may shrink when the collector frees memory of an earlier iteration
+		heap := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+		b.ReportMetric(float64(heap), "heap-bytes")
+		if baseline != 0 {
+			b.ReportMetric(100*float64(heap-baseline)/float64(baseline), "%-vs-baseline")
+		}
+		b.ReportMetric(float64(u.(udger.MemoryReporter).MemoryStats().TotalBytes()), "table-bytes")
+		runtime.KeepAlive(u)
+	}
+}
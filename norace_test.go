@@ -0,0 +1,6 @@
+//go:build !race
+
+package udger_test
+
+// raceEnabled reports that the race detector, which changes the allocations, is enabled.
+const raceEnabled = false
@@ -0,0 +1,105 @@
+package udger
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lookup types reported to an Observer.
+const (
+	LookupTypeUA = "ua"
+	LookupTypeIP = "ip"
+)
+
+// Observer is notified of the lookups and database loads, e.g. to export metrics.
+// It must be safe for concurrent use.
+type Observer interface {
+	// ObserveLookup is called after each lookup
+	ObserveLookup(e LookupEvent)
+	// ObserveLoad is called after each load of the database, successful or not
+	ObserveLoad(e LoadEvent)
+}
+
+// LookupEvent describes a lookup.
+type LookupEvent struct {
+	// Type is LookupTypeUA or LookupTypeIP
+	Type     string
+	Duration time.Duration
+	// Unmatched reports a user agent whose client or operating system is not recognized
+	Unmatched bool
+	// Regexes is the number of regexes evaluated
+	Regexes int
+	// CacheHits and CacheMisses count the accesses to the caches of the lookup
+	CacheHits   int
+	CacheMisses int
+	Err         error
+}
+
+// LoadEvent describes a load of the database.
+type LoadEvent struct {
+	// Version is the version of the database, from udger_db_info, empty when unknown
+	Version  string
+	Duration time.Duration
+	// Reload reports a load by Reloader.Reload, false for the initial load of the client
+	Reload bool
+	Err    error
+}
+
+// WithObserver notifies the observer of the lookups and database loads.
+func WithObserver(obs Observer) Option {
+	return func(o *options) {
+		o.observer = obs
+	}
+}
+
+// lookupStats collects the details of a lookup for the observer.
+type lookupStats struct {
+	regexes     int
+	cacheHits   int
+	cacheMisses int
+}
+
+func (s *lookupStats) cache(hit bool) {
+	if s == nil {
+		return
+	}
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+}
+
+func (u *udger) observeLookup(typ string, start time.Time, stats *lookupStats, unmatched bool, err error) {
+	u.opts.observer.ObserveLookup(LookupEvent{
+		Type:        typ,
+		Duration:    time.Since(start),
+		Unmatched:   unmatched,
+		Regexes:     stats.regexes,
+		CacheHits:   stats.cacheHits,
+		CacheMisses: stats.cacheMisses,
+		Err:         err,
+	})
+}
+
+func (o *options) observeLoad(start time.Time, u *udger, reload bool, err error) {
+	if o.observer == nil {
+		return
+	}
+
+	e := LoadEvent{Duration: time.Since(start), Reload: reload, Err: err}
+	if u != nil {
+		e.Version = u.dbVersion
+	}
+	o.observer.ObserveLoad(e)
+}
+
+// version reads the version of the database, the table is missing from some exports.
+func (u *udger) version() string {
+	var v string
+	if err := u.db.QueryRow(fmt.Sprintf("SELECT version FROM %s", u.opts.table("udger_db_info"))).Scan(&v); err != nil {
+		return ""
+	}
+
+	return v
+}
@@ -0,0 +1,96 @@
+package udger_test
+
+import (
+	"bytes"
+	"net/netip"
+	"sync"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	lookups []udger.LookupEvent
+	loads   []udger.LoadEvent
+}
+
+func (r *recorder) ObserveLookup(e udger.LookupEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups = append(r.lookups, e)
+}
+
+func (r *recorder) ObserveLoad(e udger.LoadEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loads = append(r.loads, e)
+}
+
+func TestObserver(t *testing.T) {
+	Convey("observe the loads and lookups", t, func() {
+		rec := &recorder{}
+		path := createTestDB(t)
+		u, err := udger.New(path, udger.WithDriver(udgertest.Driver), udger.WithObserver(rec))
+		So(err, ShouldBeNil)
+		So(rec.loads, ShouldHaveLength, 1)
+		So(rec.loads[0].Version, ShouldEqual, "20240101-01")
+		So(rec.loads[0].Err, ShouldBeNil)
+		So(rec.loads[0].Reload, ShouldBeFalse)
+
+		Convey("user agent lookups", func() {
+			_, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+			So(err, ShouldBeNil)
+			_, err = u.Lookup("unknown/1.0")
+			So(err, ShouldBeNil)
+
+			So(rec.lookups, ShouldHaveLength, 2)
+			So(rec.lookups[0].Type, ShouldEqual, udger.LookupTypeUA)
+			So(rec.lookups[0].Unmatched, ShouldBeFalse)
+			So(rec.lookups[0].Regexes, ShouldEqual, 4)
+			So(rec.lookups[1].Unmatched, ShouldBeTrue)
+			So(rec.lookups[1].Regexes, ShouldEqual, 10)
+		})
+
+		Convey("IP lookups", func() {
+			_, err := u.LookupAddr(netip.MustParseAddr("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(rec.lookups, ShouldHaveLength, 1)
+			So(rec.lookups[0].Type, ShouldEqual, udger.LookupTypeIP)
+		})
+
+		Convey("snapshots keep the version", func() {
+			var buf bytes.Buffer
+			So(udger.WriteSnapshot(&buf, u), ShouldBeNil)
+			_, err := udger.NewFromSnapshot(&buf, udger.WithObserver(rec))
+			So(err, ShouldBeNil)
+			So(rec.loads, ShouldHaveLength, 2)
+			So(rec.loads[1].Version, ShouldEqual, "20240101-01")
+		})
+
+		Convey("failed loads", func() {
+			_, err := udger.New(path+".missing", udger.WithObserver(rec))
+			So(err, ShouldNotBeNil)
+			So(rec.loads, ShouldHaveLength, 2)
+			So(rec.loads[1].Err, ShouldNotBeNil)
+			So(rec.loads[1].Reload, ShouldBeFalse)
+		})
+	})
+
+	Convey("count the cache accesses in on demand mode", t, func() {
+		rec := &recorder{}
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithOnDemand(10), udger.WithObserver(rec))
+		So(err, ShouldBeNil)
+		defer u.Close()
+
+		for i := 0; i < 2; i++ {
+			_, err := u.LookupAddr(netip.MustParseAddr("66.249.64.1"))
+			So(err, ShouldBeNil)
+		}
+		So(rec.lookups[0].CacheMisses, ShouldEqual, 2)
+		So(rec.lookups[0].CacheHits, ShouldEqual, 0)
+		So(rec.lookups[1].CacheHits, ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,104 @@
+package udger
+
+// DefaultDriver is the database/sql driver used to open the database when none is given.
+// It is registered by github.com/mattn/go-sqlite3 on cgo builds.
+const DefaultDriver = "sqlite3"
+
+// Option configures how the database is loaded.
+type Option func(*options)
+
+type options struct {
+	driver      string
+	tablePrefix string
+	tables      map[string]string
+	onDemand    bool
+	cacheSize   int
+	lookupCache int
+	versions    bool
+	rules       []Rules
+	unmatched   UnmatchedHook
+	observer    Observer
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		driver: DefaultDriver,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithDriver sets the name of the database/sql driver used to open the database,
+// e.g. "sqlite" for the pure Go driver modernc.org/sqlite.
+// The driver must be registered by the caller.
+func WithDriver(name string) Option {
+	return func(o *options) {
+		o.driver = name
+	}
+}
+
+// WithTablePrefix prefixes the name of every udger table, e.g. "udger." when the tables
+// were imported under a schema.
+func WithTablePrefix(prefix string) Option {
+	return func(o *options) {
+		o.tablePrefix = prefix
+	}
+}
+
+// WithTableName reads the given udger table, e.g. "udger_client_list", from another table.
+// The name is used as is, without the table prefix.
+func WithTableName(table, name string) Option {
+	return func(o *options) {
+		if o.tables == nil {
+			o.tables = make(map[string]string)
+		}
+		o.tables[table] = name
+	}
+}
+
+// table returns the name to query for the given udger table.
+func (o *options) table(name string) string {
+	if n, ok := o.tables[name]; ok {
+		return n
+	}
+
+	return o.tablePrefix + name
+}
+
+// WithOnDemand keeps the database open and serves the IP and crawler lists with indexed
+// queries instead of loading them in memory. The last cacheSize rows of each table are
+// cached, DefaultCacheSize is a sensible default. Close must be called to release the database.
+func WithOnDemand(cacheSize int) Option {
+	return func(o *options) {
+		o.onDemand = true
+		o.cacheSize = cacheSize
+	}
+}
+
+// WithLookupCache caches the results of the last size user agents looked up, the common user
+// agents are then looked up without evaluating the regexes nor allocating with LookupInto.
+// The user agents longer than 512 bytes are not cached.
+func WithLookupCache(size int) Option {
+	return func(o *options) {
+		o.lookupCache = size
+	}
+}
+
+// WithBrowserVersion reads the version of the client from the first capture group of its regex,
+// e.g. 8.0 for /msie ([0-9a-z\._]+)/si. Browser.Version is then set and Browser.Name is the family
+// followed by the version. Each lookup evaluating the client regexes allocates the submatches.
+func WithBrowserVersion() Option {
+	return func(o *options) {
+		o.versions = true
+	}
+}
+
+// WithRules adds custom rules and overrides on top of the database, see Rules.
+func WithRules(rules ...Rules) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
@@ -0,0 +1,73 @@
+package udger
+
+// IsMobile reports a smartphone, a PDA or a wearable computer, the tablets are reported by IsTablet.
+func (i *Info) IsMobile() bool {
+	return i.deviceIs(DeviceClassSmartphone, DeviceClassPDA, DeviceClassWearableComputer)
+}
+
+// IsTablet reports a tablet.
+func (i *Info) IsTablet() bool {
+	return i.deviceIs(DeviceClassTablet)
+}
+
+// IsDesktop reports a personal computer.
+func (i *Info) IsDesktop() bool {
+	return i.deviceIs(DeviceClassDesktop)
+}
+
+// IsSmartTV reports a smart TV.
+func (i *Info) IsSmartTV() bool {
+	return i.deviceIs(DeviceClassSmartTV)
+}
+
+// IsBot reports a crawler user agent, matched exactly by Lookup against the user agents of
+// udger_crawler_list. The crawlers hiding their user agent are only detected by IPInfo.IsBot.
+func (i *Info) IsBot() bool {
+	return i != nil && i.Browser.Class == ClientClassCrawler
+}
+
+func (i *Info) deviceIs(classes ...DeviceClass) bool {
+	if i == nil {
+		return false
+	}
+	for _, c := range classes {
+		if i.Device.Class == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBot reports the IP of a known crawler.
+func (i *IPInfo) IsBot() bool {
+	return i != nil && (i.Crawler.ID != 0 || i.ipClassIs(crawlerIPClasses...))
+}
+
+// IsDatacenter reports an IP in the range of a datacenter, e.g. a cloud provider.
+func (i *IPInfo) IsDatacenter() bool {
+	return i != nil && i.DataCenter.ID != 0
+}
+
+// IsTor reports a tor exit node.
+func (i *IPInfo) IsTor() bool {
+	return i.ipClassIs(IPClassTorExitNode)
+}
+
+// IsProxy reports a web or CGI proxy. The tor exit nodes are reported by IsTor.
+func (i *IPInfo) IsProxy() bool {
+	return i.ipClassIs(proxyIPClasses...)
+}
+
+func (i *IPInfo) ipClassIs(codes ...string) bool {
+	if i == nil {
+		return false
+	}
+	for _, c := range codes {
+		if i.IPClass.IPClassificationCode == c {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+package udger_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func deviceInfo(class udger.DeviceClass) *udger.Info {
+	return &udger.Info{Device: udger.Device{Class: class}}
+}
+
+func TestInfoPredicates(t *testing.T) {
+	tests := []struct {
+		name string
+		info *udger.Info
+		want []string
+	}{
+		{name: "nil", info: nil},
+		{name: "empty", info: &udger.Info{}},
+		{name: "smartphone", info: deviceInfo(udger.DeviceClassSmartphone), want: []string{"mobile"}},
+		{name: "pda", info: deviceInfo(udger.DeviceClassPDA), want: []string{"mobile"}},
+		{name: "wearable", info: deviceInfo(udger.DeviceClassWearableComputer), want: []string{"mobile"}},
+		{name: "tablet", info: deviceInfo(udger.DeviceClassTablet), want: []string{"tablet"}},
+		{name: "desktop", info: deviceInfo(udger.DeviceClassDesktop), want: []string{"desktop"}},
+		{name: "smart tv", info: deviceInfo(udger.DeviceClassSmartTV), want: []string{"smart tv"}},
+		{name: "game console", info: deviceInfo(udger.DeviceClassGameConsole)},
+		{name: "display name only", info: &udger.Info{Device: udger.Device{Name: "Smartphone"}}},
+		{name: "crawler", info: &udger.Info{Browser: udger.Browser{Class: udger.ClientClassCrawler}, Device: udger.Device{Class: udger.DeviceClassOther}}, want: []string{"bot"}},
+		{name: "library", info: &udger.Info{Browser: udger.Browser{Class: udger.ClientClassLibrary}}},
+	}
+
+	for _, tt := range tests {
+		Convey("predicates of "+tt.name, t, func() {
+			var got []string
+			for _, p := range []struct {
+				name string
+				is   func() bool
+			}{
+				{"mobile", tt.info.IsMobile},
+				{"tablet", tt.info.IsTablet},
+				{"desktop", tt.info.IsDesktop},
+				{"smart tv", tt.info.IsSmartTV},
+				{"bot", tt.info.IsBot},
+			} {
+				if p.is() {
+					got = append(got, p.name)
+				}
+			}
+			So(got, ShouldResemble, tt.want)
+		})
+	}
+}
+
+func TestIPInfoPredicates(t *testing.T) {
+	tests := []struct {
+		name string
+		info *udger.IPInfo
+		want []string
+	}{
+		{name: "nil", info: nil},
+		{name: "empty", info: &udger.IPInfo{}},
+		{name: "crawler", info: ipInfo("crawler", "search_engine_bot", ""), want: []string{"bot"}},
+		{name: "crawler class only", info: ipInfo("cross_crawler", "", ""), want: []string{"bot"}},
+		{name: "crawler in datacenter", info: ipInfo("", "search_engine_bot", "google"), want: []string{"bot", "datacenter"}},
+		{name: "fake crawler", info: ipInfo("fake_crawler", "", "")},
+		{name: "datacenter", info: ipInfo("", "", "amazon_aws"), want: []string{"datacenter"}},
+		{name: "tor", info: ipInfo("tor_exit_node", "", ""), want: []string{"tor"}},
+		{name: "web proxy", info: ipInfo("web_proxy", "", ""), want: []string{"proxy"}},
+		{name: "cgi proxy in datacenter", info: ipInfo("cgi_proxy", "", "ovh"), want: []string{"datacenter", "proxy"}},
+		{name: "vpn", info: ipInfo("vpn_service", "", "")},
+		{name: "display name only", info: &udger.IPInfo{IPClass: udger.IPClass{IPClassification: "Tor exit node"}}},
+	}
+
+	for _, tt := range tests {
+		Convey("predicates of "+tt.name, t, func() {
+			var got []string
+			for _, p := range []struct {
+				name string
+				is   func() bool
+			}{
+				{"bot", tt.info.IsBot},
+				{"datacenter", tt.info.IsDatacenter},
+				{"proxy", tt.info.IsProxy},
+				{"tor", tt.info.IsTor},
+			} {
+				if p.is() {
+					got = append(got, p.name)
+				}
+			}
+			So(got, ShouldResemble, tt.want)
+		})
+	}
+}
+
+func TestPredicatesOnLookups(t *testing.T) {
+	Convey("the predicates match the lookups", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup("Mozilla/5.0 (iPhone; CPU iPhone OS 9_2_1 like Mac OS X) AppleWebKit/601.1.46 (KHTML, like Gecko) Mobile/13D15")
+		So(err, ShouldBeNil)
+		So(info.IsMobile(), ShouldBeTrue)
+		So(info.IsDesktop(), ShouldBeFalse)
+
+		info, err = u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		So(info.IsDesktop(), ShouldBeTrue)
+		So(info.IsBot(), ShouldBeFalse)
+
+		ipInfo, err := u.LookupIP(net.ParseIP("66.249.64.1"))
+		So(err, ShouldBeNil)
+		So(ipInfo.IsBot(), ShouldBeTrue)
+		So(ipInfo.IsDatacenter(), ShouldBeTrue)
+		So(ipInfo.IsTor(), ShouldBeFalse)
+	})
+}
+
+func TestCrawlerLookup(t *testing.T) {
+	const bingbot = "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)"
+
+	Convey("match the user agents of the crawler list", t, func() {
+		path := createTestDB(t)
+		mem, err := udger.New(path, udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+		var buf bytes.Buffer
+		So(udger.WriteSnapshot(&buf, mem), ShouldBeNil)
+		snap, err := udger.NewFromSnapshot(&buf)
+		So(err, ShouldBeNil)
+		onDemand, err := udger.New(path, udger.WithDriver(udgertest.Driver), udger.WithOnDemand(udger.DefaultCacheSize))
+		So(err, ShouldBeNil)
+		defer onDemand.Close()
+
+		for name, u := range map[string]udger.Client{"in memory": mem, "snapshot": snap, "on demand": onDemand} {
+			u := u
+			Convey(name, func() {
+				info, err := u.Lookup("Googlebot/2.1")
+				So(err, ShouldBeNil)
+				So(info.IsBot(), ShouldBeTrue)
+				So(info.Browser.Family, ShouldEqual, "Googlebot")
+				So(info.Browser.Name, ShouldEqual, "Googlebot/2.1")
+				So(info.Browser.Version, ShouldEqual, "2.1")
+				So(info.Browser.Type, ShouldEqual, "Crawler")
+				So(info.Browser.Company, ShouldEqual, "Google Inc.")
+				So(info.Recognized.Browser, ShouldBeTrue)
+				So(info.Device.Class, ShouldEqual, udger.DeviceClassOther)
+
+				info, err = u.Lookup(bingbot)
+				So(err, ShouldBeNil)
+				So(info.IsBot(), ShouldBeTrue)
+				So(info.Browser.Family, ShouldEqual, "Bingbot")
+
+				info, err = u.Lookup("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+				So(err, ShouldBeNil)
+				So(info.IsBot(), ShouldBeFalse)
+			})
+		}
+	})
+
+	Convey("the crawlers are not reported as unmatched", t, func() {
+		c := udger.NewUnmatchedCollector(0)
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithUnmatchedHook(c.Record))
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup(bingbot)
+		So(err, ShouldBeNil)
+		So(info.Recognized.OS, ShouldBeFalse)
+		So(c.Unmatched(), ShouldBeEmpty)
+	})
+}
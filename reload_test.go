@@ -0,0 +1,147 @@
+package udger_test
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const firefox = "Mozilla/5.0 (Windows NT 6.1; rv:121.0) Gecko/20100101 Firefox/121.0"
+
+// updateTestDB replaces the database at path with the default fixture and a Firefox client,
+// like an updater would.
+func updateTestDB(t *testing.T, path string) {
+	t.Helper()
+
+	d := udgertest.Default()
+	d.Version = "20240201-01"
+	d.Clients = append(d.Clients, udgertest.Client{ID: 7, ClassID: 1, Name: "Firefox", Regexes: []string{`/firefox\/([0-9\.]+)/si`}})
+	if err := os.Rename(udgertest.Create(t, d), path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	Convey("reload the database", t, func() {
+		rec := &recorder{}
+		path := createTestDB(t)
+		u, err := udger.New(path, udger.WithDriver(udgertest.Driver), udger.WithObserver(rec), udger.WithLookupCache(10))
+		So(err, ShouldBeNil)
+
+		r, ok := u.(udger.Reloader)
+		So(ok, ShouldBeTrue)
+
+		info, err := u.Lookup(firefox)
+		So(err, ShouldBeNil)
+		So(info.Recognized.Browser, ShouldBeFalse)
+
+		Convey("swap in the updated database", func() {
+			updateTestDB(t, path)
+			So(r.Reload(), ShouldBeNil)
+
+			info, err := u.Lookup(firefox)
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "Firefox")
+			So(info.CacheHit, ShouldBeFalse)
+			So(u.(udger.Catalog).BrowserFamilies(), ShouldContain, "Firefox")
+			So(rec.loads, ShouldHaveLength, 2)
+			So(rec.loads[1].Version, ShouldEqual, "20240201-01")
+			So(rec.loads[1].Reload, ShouldBeTrue)
+		})
+
+		Convey("keep the previous database on failure", func() {
+			So(os.Remove(path), ShouldBeNil)
+			So(r.Reload(), ShouldNotBeNil)
+
+			info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "IE")
+			So(rec.loads, ShouldHaveLength, 2)
+			So(rec.loads[1].Err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("a snapshot can not be reloaded", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		var buf bytes.Buffer
+		So(udger.WriteSnapshot(&buf, u), ShouldBeNil)
+		c, err := udger.NewFromSnapshot(&buf)
+		So(err, ShouldBeNil)
+		So(c.(udger.Reloader).Reload(), ShouldEqual, udger.ErrReloadUnsupported)
+	})
+}
+
+func TestConcurrentReload(t *testing.T) {
+	for name, opts := range map[string][]udger.Option{
+		"in memory": {udger.WithLookupCache(10)},
+		"on demand": {udger.WithOnDemand(udger.DefaultCacheSize)},
+	} {
+		opts := opts
+		t.Run(name, func(t *testing.T) {
+			path := createTestDB(t)
+			u, err := udger.New(path, append(opts, udger.WithDriver(udgertest.Driver))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer u.Close()
+
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var info udger.Info
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						if err := u.(udger.IntoLookuper).LookupInto(firefox, &info); err != nil {
+							t.Error(err)
+							return
+						}
+						ipInfo, err := u.LookupIP(net.ParseIP("66.249.64.1"))
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						if ipInfo.Crawler.Family != "Googlebot" {
+							t.Errorf("unexpected crawler %q", ipInfo.Crawler.Family)
+							return
+						}
+						u.(udger.Catalog).Crawlers(udger.CatalogFilter{})
+					}
+				}()
+			}
+
+			for i := 0; i < 10; i++ {
+				if i == 5 {
+					updateTestDB(t, path)
+				}
+				if err := u.(udger.Reloader).Reload(); err != nil {
+					t.Error(err)
+				}
+			}
+			close(done)
+			wg.Wait()
+
+			info, err := u.Lookup(firefox)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Browser.Family != "Firefox" {
+				t.Errorf("the updated database is not used, got %q", info.Browser.Family)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package udger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// customIDBase is the first ID given to the custom entries defined without ID, far above the udger IDs.
+const customIDBase = 1 << 30
+
+// Priority sets when a custom rule is evaluated relatively to the rules of the database.
+type Priority int
+
+// Priorities of the custom rules.
+const (
+	// BeforeUdger rules are evaluated before the rules of the database and take precedence
+	BeforeUdger Priority = iota
+	// AfterUdger rules are evaluated only when no rule of the database matched
+	AfterUdger
+)
+
+// String returns the code of the priority.
+func (p Priority) String() string {
+	if p == AfterUdger {
+		return "after"
+	}
+
+	return "before"
+}
+
+// MarshalText encodes the priority as "before" or "after".
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes the priority from "before" or "after".
+func (p *Priority) UnmarshalText(b []byte) error {
+	switch strings.ToLower(string(b)) {
+	case "", "before":
+		*p = BeforeUdger
+	case "after":
+		*p = AfterUdger
+	default:
+		return fmt.Errorf("udger: invalid priority %q", b)
+	}
+
+	return nil
+}
+
+// Rules are custom rules and overrides applied on top of the database, see WithRules.
+// The regexes use the udger syntax, e.g. /myapp\/([0-9.]+)/si, and are case insensitive.
+type Rules struct {
+	Clients   []ClientRule `json:"clients" yaml:"clients"`
+	OS        []OSRule     `json:"os" yaml:"os"`
+	Devices   []DeviceRule `json:"devices" yaml:"devices"`
+	Overrides Overrides    `json:"overrides" yaml:"overrides"`
+}
+
+// ClientRule detects a client. It points to an existing client of the database with ID,
+// or defines a new client with Client, which gets a free ID when ID is not set.
+// The Type of the new client is the name of its class, e.g. "Mobile browser", and Class its code,
+// e.g. "mobile_browser", derived from the name when empty.
+type ClientRule struct {
+	Regex    string   `json:"regex" yaml:"regex"`
+	Priority Priority `json:"priority" yaml:"priority"`
+	ID       int      `json:"id" yaml:"id"`
+	Client   *Browser `json:"client" yaml:"client"`
+}
+
+// OSRule detects an operating system, like ClientRule.
+type OSRule struct {
+	Regex    string   `json:"regex" yaml:"regex"`
+	Priority Priority `json:"priority" yaml:"priority"`
+	ID       int      `json:"id" yaml:"id"`
+	OS       *OS      `json:"os" yaml:"os"`
+}
+
+// DeviceRule detects a device class, like ClientRule.
+type DeviceRule struct {
+	Regex    string   `json:"regex" yaml:"regex"`
+	Priority Priority `json:"priority" yaml:"priority"`
+	ID       int      `json:"id" yaml:"id"`
+	Device   *Device  `json:"device" yaml:"device"`
+}
+
+// Overrides replace the attributes of existing entries by ID, only the non empty attributes are replaced.
+type Overrides struct {
+	Clients map[int]Browser `json:"clients" yaml:"clients"`
+	OS      map[int]OS      `json:"os" yaml:"os"`
+	Devices map[int]Device  `json:"devices" yaml:"devices"`
+}
+
+// ReadRules decodes rules from JSON or YAML.
+func ReadRules(r io.Reader) (Rules, error) {
+	var rules Rules
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&rules); err != nil && err != io.EOF {
+		return Rules{}, fmt.Errorf("udger: invalid rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// LoadRules reads rules from a JSON or YAML file.
+func LoadRules(path string) (Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Rules{}, err
+	}
+	defer f.Close()
+
+	return ReadRules(f)
+}
+
+// applyRules adds the custom rules and overrides to the loaded database. The new IDs
+// follow the custom entries already defined, e.g. by the rules of a restored snapshot.
+func (u *udger) applyRules(rules []Rules) error {
+	nextID := maxCustomID(u.browsers, customIDBase)
+	nextID = maxCustomID(u.os, nextID)
+	nextID = maxCustomID(u.devices, nextID)
+	newID := func(id int) int {
+		if id != 0 {
+			return id
+		}
+		nextID++
+		return nextID
+	}
+
+	for _, r := range rules {
+		var before, after []rexData
+		add := func(i int, kind string, id int, regex string, p Priority) error {
+			d := rexData{ID: id, Regex: u.cleanRegex(regex)}
+			if err := d.compile(); err != nil {
+				return fmt.Errorf("udger: %s rule %d: %w", kind, i, err)
+			}
+			if p == AfterUdger {
+				after = append(after, d)
+			} else {
+				before = append(before, d)
+			}
+			return nil
+		}
+
+		for i, rule := range r.Clients {
+			id := newID(rule.ID)
+			if err := u.defineBrowser(id, rule); err != nil {
+				return fmt.Errorf("udger: client rule %d: %w", i, err)
+			}
+			if err := add(i, "client", id, rule.Regex, rule.Priority); err != nil {
+				return err
+			}
+		}
+		u.rexBrowsers = concatRules(before, u.rexBrowsers, after)
+
+		before, after = nil, nil
+		for i, rule := range r.OS {
+			id := newID(rule.ID)
+			if err := defineEntry(u.os, id, rule.OS, func(o *OS) { o.ID = id }); err != nil {
+				return fmt.Errorf("udger: os rule %d: %w", i, err)
+			}
+			if err := add(i, "os", id, rule.Regex, rule.Priority); err != nil {
+				return err
+			}
+		}
+		u.rexOS = concatRules(before, u.rexOS, after)
+
+		before, after = nil, nil
+		for i, rule := range r.Devices {
+			id := newID(rule.ID)
+			if err := defineEntry(u.devices, id, rule.Device, func(d *Device) { d.ID = id }); err != nil {
+				return fmt.Errorf("udger: device rule %d: %w", i, err)
+			}
+			if err := add(i, "device", id, rule.Regex, rule.Priority); err != nil {
+				return err
+			}
+		}
+		u.rexDevices = concatRules(before, u.rexDevices, after)
+
+		if err := u.applyOverrides(r.Overrides); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *udger) defineBrowser(id int, rule ClientRule) error {
+	if rule.Client == nil {
+		if _, ok := u.browsers[id]; !ok {
+			return fmt.Errorf("unknown client %d", id)
+		}
+		return nil
+	}
+	if _, ok := u.browsers[id]; ok {
+		return fmt.Errorf("client %d already exists, use an override", id)
+	}
+
+	b := *rule.Client
+	b.ID = id
+	b.typ = u.browserClass(b.Type, b.Class)
+	b.Type = ""
+	b.Class = ""
+	u.browsers[id] = b
+
+	return nil
+}
+
+// browserClass returns the ID of the client class with the given name, adding it with the code when needed.
+func (u *udger) browserClass(name string, code ClientClass) int {
+	if name == "" {
+		return 0
+	}
+
+	next := customIDBase
+	for id, n := range u.browserTypes {
+		if strings.EqualFold(n, name) {
+			return id
+		}
+		if id > next {
+			next = id
+		}
+	}
+	if code == "" {
+		code = classCode(name)
+	}
+	u.browserTypes[next+1] = name
+	u.browserClasses[next+1] = code
+
+	return next + 1
+}
+
+// sortedIDs returns the IDs of m in increasing order.
+func sortedIDs[T any](m map[int]T) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+// maxCustomID returns the highest ID of m, or next when it is higher.
+func maxCustomID[T any](m map[int]T, next int) int {
+	for id := range m {
+		if id > next {
+			next = id
+		}
+	}
+
+	return next
+}
+
+func defineEntry[T any](m map[int]T, id int, def *T, setID func(*T)) error {
+	if def == nil {
+		if _, ok := m[id]; !ok {
+			return fmt.Errorf("unknown entry %d", id)
+		}
+		return nil
+	}
+	if _, ok := m[id]; ok {
+		return fmt.Errorf("entry %d already exists, use an override", id)
+	}
+
+	v := *def
+	setID(&v)
+	m[id] = v
+
+	return nil
+}
+
+func concatRules(before, rules, after []rexData) []rexData {
+	if len(before) == 0 && len(after) == 0 {
+		return rules
+	}
+
+	out := make([]rexData, 0, len(before)+len(rules)+len(after))
+	out = append(out, before...)
+	out = append(out, rules...)
+
+	return append(out, after...)
+}
+
+func (u *udger) applyOverrides(o Overrides) error {
+	// in ID order, the client classes added by the overrides get the same IDs on every load
+	for _, id := range sortedIDs(o.Clients) {
+		v := o.Clients[id]
+		b, ok := u.browsers[id]
+		if !ok {
+			return fmt.Errorf("udger: override of unknown client %d", id)
+		}
+		override(&b.Family, v.Family)
+		override(&b.Engine, v.Engine)
+		override(&b.Company, v.Company)
+		override(&b.Icon, v.Icon)
+		if v.Type != "" {
+			b.typ = u.browserClass(v.Type, v.Class)
+		}
+		u.browsers[id] = b
+	}
+	for _, id := range sortedIDs(o.OS) {
+		v := o.OS[id]
+		os, ok := u.os[id]
+		if !ok {
+			return fmt.Errorf("udger: override of unknown os %d", id)
+		}
+		override(&os.Name, v.Name)
+		override(&os.Family, v.Family)
+		override(&os.FamilyCode, v.FamilyCode)
+		override(&os.Company, v.Company)
+		override(&os.Icon, v.Icon)
+		u.os[id] = os
+	}
+	for _, id := range sortedIDs(o.Devices) {
+		v := o.Devices[id]
+		d, ok := u.devices[id]
+		if !ok {
+			return fmt.Errorf("udger: override of unknown device %d", id)
+		}
+		override(&d.Name, v.Name)
+		override(&d.Icon, v.Icon)
+		if v.Class != "" {
+			d.Class = v.Class
+		}
+		u.devices[id] = d
+	}
+
+	return nil
+}
+
+func override(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
@@ -0,0 +1,178 @@
+package udger_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testRulesYAML = `
+clients:
+  - regex: /myapp\/([0-9.]+)/si
+    priority: after
+    client:
+      family: MyApp
+      company: ACME
+      type: Mobile app
+  - regex: /trident\/4\.0/si
+    id: 2
+os:
+  - regex: /myos/si
+    os:
+      name: MyOS 1
+      family: MyOS
+devices:
+  - regex: /myapp/si
+    id: 3
+overrides:
+  clients:
+    3:
+      company: curl project
+  os:
+    2:
+      icon: win7.png
+`
+
+const testRulesJSON = `{"clients": [{"regex": "/myapp/si", "priority": "after", "id": 3}]}`
+
+func TestReadRules(t *testing.T) {
+	Convey("decode rules", t, func() {
+		Convey("from YAML", func() {
+			rules, err := udger.ReadRules(strings.NewReader(testRulesYAML))
+			So(err, ShouldBeNil)
+			So(rules.Clients, ShouldHaveLength, 2)
+			So(rules.Clients[0].Priority, ShouldEqual, udger.AfterUdger)
+			So(rules.Clients[0].Client.Family, ShouldEqual, "MyApp")
+			So(rules.Clients[1].Priority, ShouldEqual, udger.BeforeUdger)
+			So(rules.Clients[1].ID, ShouldEqual, 2)
+			So(rules.OS[0].OS.Name, ShouldEqual, "MyOS 1")
+			So(rules.Devices[0].ID, ShouldEqual, 3)
+			So(rules.Overrides.Clients[3].Company, ShouldEqual, "curl project")
+		})
+
+		Convey("from JSON", func() {
+			rules, err := udger.ReadRules(strings.NewReader(testRulesJSON))
+			So(err, ShouldBeNil)
+			So(rules.Clients, ShouldHaveLength, 1)
+			So(rules.Clients[0].Priority, ShouldEqual, udger.AfterUdger)
+		})
+
+		Convey("reject unknown fields and priorities", func() {
+			_, err := udger.ReadRules(strings.NewReader(`clients: [{regexp: /a/}]`))
+			So(err, ShouldNotBeNil)
+
+			_, err = udger.ReadRules(strings.NewReader(`clients: [{regex: /a/, priority: first}]`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWithRules(t *testing.T) {
+	Convey("apply custom rules on top of the database", t, func() {
+		rules, err := udger.ReadRules(strings.NewReader(testRulesYAML))
+		So(err, ShouldBeNil)
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithRules(rules))
+		So(err, ShouldBeNil)
+		defer u.Close()
+
+		Convey("before rules take precedence over the database", func() {
+			info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "Chrome")
+			So(info.OS.Icon, ShouldEqual, "win7.png")
+			So(info.OS.Company, ShouldEqual, "Microsoft Corporation.")
+		})
+
+		Convey("after rules match what the database does not", func() {
+			info, err := u.Lookup("MyApp/1.2 (MyOS)")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "MyApp")
+			So(info.Browser.Company, ShouldEqual, "ACME")
+			So(info.Browser.Type, ShouldEqual, "Mobile app")
+			So(info.Browser.Class, ShouldEqual, udger.ClientClass("mobile_app"))
+			So(info.OS.Family, ShouldEqual, "MyOS")
+			So(info.Device.Name, ShouldEqual, "Smartphone")
+		})
+
+		Convey("new entries are in the catalog", func() {
+			c := u.(udger.Catalog)
+			So(c.Browsers(udger.CatalogFilter{Family: "myapp"}), ShouldHaveLength, 1)
+			So(c.Browsers(udger.CatalogFilter{Vendor: "curl project"}), ShouldHaveLength, 1)
+			So(c.OSFamilies(), ShouldContain, "MyOS")
+		})
+	})
+
+	Convey("apply custom rules on top of a snapshot written with rules", t, func() {
+		rules, err := udger.ReadRules(strings.NewReader(testRulesYAML))
+		So(err, ShouldBeNil)
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithRules(rules))
+		So(err, ShouldBeNil)
+		defer u.Close()
+
+		var buf bytes.Buffer
+		So(udger.WriteSnapshot(&buf, u), ShouldBeNil)
+
+		more, err := udger.ReadRules(strings.NewReader(`
+clients:
+  - regex: /otherapp/si
+    client:
+      family: OtherApp
+os:
+  - regex: /otheros/si
+    os:
+      name: OtherOS
+`))
+		So(err, ShouldBeNil)
+		s, err := udger.NewFromSnapshot(&buf, udger.WithRules(more))
+		So(err, ShouldBeNil)
+
+		mine, err := s.Lookup("MyApp/1.2 (MyOS)")
+		So(err, ShouldBeNil)
+		So(mine.Browser.Family, ShouldEqual, "MyApp")
+		So(mine.OS.Family, ShouldEqual, "MyOS")
+
+		other, err := s.Lookup("OtherApp (OtherOS)")
+		So(err, ShouldBeNil)
+		So(other.Browser.Family, ShouldEqual, "OtherApp")
+		So(other.OS.Name, ShouldEqual, "OtherOS")
+		So(other.Browser.ID, ShouldNotEqual, mine.Browser.ID)
+		So(other.OS.ID, ShouldNotEqual, mine.OS.ID)
+	})
+
+	Convey("apply the overrides in ID order", t, func() {
+		path := createTestDB(t)
+		rules := udger.Rules{Overrides: udger.Overrides{Clients: map[int]udger.Browser{
+			1: {Type: "Robot", Class: udger.ClientClassCrawler},
+			2: {Type: "robot", Class: udger.ClientClassOther},
+			4: {Type: "Script", Class: udger.ClientClassLibrary},
+		}}}
+		for i := 0; i < 20; i++ {
+			u, err := udger.New(path, udger.WithDriver(udgertest.Driver), udger.WithRules(rules))
+			So(err, ShouldBeNil)
+
+			info, err := u.Lookup("Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2575.0 Safari/537.36")
+			So(err, ShouldBeNil)
+			So(info.Browser.Type, ShouldEqual, "Robot")
+			So(info.Browser.Class, ShouldEqual, udger.ClientClassCrawler)
+		}
+	})
+
+	Convey("reject invalid rules", t, func() {
+		path := createTestDB(t)
+		tests := []udger.Rules{
+			{Clients: []udger.ClientRule{{Regex: "/a/", ID: 42}}},
+			{Clients: []udger.ClientRule{{Regex: "/a/", ID: 1, Client: &udger.Browser{Family: "IE"}}}},
+			{Clients: []udger.ClientRule{{Regex: "/(a/", ID: 1}}},
+			{OS: []udger.OSRule{{Regex: "/a/", ID: 42}}},
+			{Overrides: udger.Overrides{Devices: map[int]udger.Device{42: {Name: "TV"}}}},
+		}
+		for _, rules := range tests {
+			_, err := udger.New(path, udger.WithDriver(udgertest.Driver), udger.WithRules(rules))
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
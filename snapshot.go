@@ -0,0 +1,263 @@
+package udger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot format written by WriteSnapshot.
+// It must be bumped every time the encoded layout changes.
+const snapshotVersion uint32 = 8
+
+var snapshotMagic = [8]byte{'U', 'D', 'G', 'E', 'R', 'S', 'N', 'P'}
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrInvalidSnapshot is returned when the data is not a udger snapshot or is corrupted.
+	ErrInvalidSnapshot = errors.New("udger: invalid snapshot")
+	// ErrSnapshotVersion is returned when the snapshot was written by an incompatible version of the package.
+	ErrSnapshotVersion = errors.New("udger: unsupported snapshot version")
+	// ErrSnapshotUnsupported is returned when the client cannot be exported to a snapshot,
+	// e.g. when it was created in on demand mode.
+	ErrSnapshotUnsupported = errors.New("udger: client does not support snapshots")
+)
+
+// snapshot is the serialized form of the in memory database.
+type snapshot struct {
+	Version          string
+	BrowserRegexes   []snapshotRegex
+	DeviceRegexes    []snapshotRegex
+	OSRegexes        []snapshotRegex
+	BrowserTypes     map[int]string
+	BrowserClasses   map[int]ClientClass
+	ClassDevices     map[int]int
+	BrowserOS        map[int]int
+	Browsers         map[int]snapshotBrowser
+	OS               map[int]OS
+	Devices          map[int]Device
+	IP               map[netip.Addr]IP
+	SkippedIP        int
+	IPClass          map[int]IPClass
+	Crawler          map[int]Crawler
+	CrawlerClass     map[int]CrawlerClass
+	DataCenter       map[int]DataCenter
+	DataCenterRange  []dcRange4
+	DataCenterRange6 []dcRange6
+}
+
+type snapshotRegex struct {
+	ID    int
+	Regex string
+}
+
+type snapshotBrowser struct {
+	Browser Browser
+	Class   int
+}
+
+// WriteSnapshot writes the database loaded in the client to w in a compact binary format
+// that can be loaded back with NewFromSnapshot, without the need of SQLite.
+func WriteSnapshot(w io.Writer, c Client) error {
+	cl, ok := c.(*client)
+	if !ok {
+		return ErrSnapshotUnsupported
+	}
+	u := cl.acquire()
+	defer u.release()
+	if u.store != nil {
+		return ErrSnapshotUnsupported
+	}
+
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(u.snapshot()); err != nil {
+		return err
+	}
+
+	var header [20]byte
+	copy(header[:8], snapshotMagic[:])
+	binary.BigEndian.PutUint32(header[8:12], snapshotVersion)
+	binary.BigEndian.PutUint64(header[12:20], uint64(payload.Len()))
+
+	var trailer [4]byte
+	binary.BigEndian.PutUint32(trailer[:], crc32.Checksum(payload.Bytes(), crcTable))
+
+	for _, b := range [][]byte{header[:], payload.Bytes(), trailer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewFromSnapshot creates a new instance of Udger from a snapshot written by WriteSnapshot.
+// The regexes are compiled again but no database access is needed. The custom rules the
+// snapshot was written with are part of it, the options only apply the rules given here.
+// The client can not be reloaded.
+func NewFromSnapshot(r io.Reader, opts ...Option) (Client, error) {
+	o := newOptions(opts)
+	start := time.Now()
+	u, err := newFromSnapshot(r, o)
+	o.observeLoad(start, u, false, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(u, nil), nil
+}
+
+func newFromSnapshot(r io.Reader, o *options) (*udger, error) {
+	br := bufio.NewReader(r)
+
+	var header [20]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if !bytes.Equal(header[:8], snapshotMagic[:]) {
+		return nil, ErrInvalidSnapshot
+	}
+	if v := binary.BigEndian.Uint32(header[8:12]); v != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, v)
+	}
+
+	var payload bytes.Buffer
+	size := int64(binary.BigEndian.Uint64(header[12:20]))
+	if n, err := io.CopyN(&payload, br, size); err != nil || n != size {
+		return nil, fmt.Errorf("%w: truncated payload", ErrInvalidSnapshot)
+	}
+
+	var trailer [4]byte
+	if _, err := io.ReadFull(br, trailer[:]); err != nil {
+		return nil, fmt.Errorf("%w: missing checksum", ErrInvalidSnapshot)
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != crc32.Checksum(payload.Bytes(), crcTable) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+
+	var s snapshot
+	if err := gob.NewDecoder(&payload).Decode(&s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	u := newUdger()
+	u.opts = o
+	if err := u.restore(&s); err != nil {
+		return nil, err
+	}
+	if err := u.applyRules(o.rules); err != nil {
+		return nil, err
+	}
+	u.uaCache = newLookupCache(o)
+
+	return u, nil
+}
+
+func (u *udger) snapshot() *snapshot {
+	s := &snapshot{
+		Version:          u.dbVersion,
+		BrowserRegexes:   snapshotRegexes(u.rexBrowsers),
+		DeviceRegexes:    snapshotRegexes(u.rexDevices),
+		OSRegexes:        snapshotRegexes(u.rexOS),
+		BrowserTypes:     u.browserTypes,
+		BrowserClasses:   u.browserClasses,
+		ClassDevices:     u.classDevices,
+		BrowserOS:        u.browserOS,
+		Browsers:         make(map[int]snapshotBrowser, len(u.browsers)),
+		OS:               u.os,
+		Devices:          u.devices,
+		IP:               u.ips,
+		SkippedIP:        u.skippedIPs,
+		IPClass:          u.ipClasses,
+		Crawler:          u.crawlers,
+		CrawlerClass:     u.crawlerClasses,
+		DataCenter:       u.dataCenters,
+		DataCenterRange:  u.dcRanges4,
+		DataCenterRange6: u.dcRanges6,
+	}
+	for id, b := range u.browsers {
+		s.Browsers[id] = snapshotBrowser{Browser: b, Class: b.typ}
+	}
+
+	return s
+}
+
+func (u *udger) restore(s *snapshot) error {
+	u.dbVersion = s.Version
+
+	var err error
+	if u.rexBrowsers, err = restoreRegexes(s.BrowserRegexes); err != nil {
+		return err
+	}
+	if u.rexDevices, err = restoreRegexes(s.DeviceRegexes); err != nil {
+		return err
+	}
+	if u.rexOS, err = restoreRegexes(s.OSRegexes); err != nil {
+		return err
+	}
+
+	for id, b := range s.Browsers {
+		b.Browser.typ = b.Class
+		u.browsers[id] = b.Browser
+	}
+	copyMap(u.browserTypes, s.BrowserTypes)
+	copyMap(u.browserClasses, s.BrowserClasses)
+	copyMap(u.classDevices, s.ClassDevices)
+	copyMap(u.browserOS, s.BrowserOS)
+	copyMap(u.os, s.OS)
+	copyMap(u.devices, s.Devices)
+	copyMap(u.ipClasses, s.IPClass)
+	copyMap(u.crawlerClasses, s.CrawlerClass)
+	copyMap(u.dataCenters, s.DataCenter)
+	u.dcRanges4 = s.DataCenterRange
+	u.dcRanges6 = s.DataCenterRange6
+	u.skippedIPs = s.SkippedIP
+
+	in := make(interner)
+	for addr, ip := range s.IP {
+		in.ip(&ip)
+		u.ips[addr] = ip
+	}
+	for id, c := range s.Crawler {
+		in.crawler(&c)
+		u.crawlers[id] = c
+		u.indexCrawler(c.UA, id)
+	}
+
+	return nil
+}
+
+func snapshotRegexes(data []rexData) []snapshotRegex {
+	out := make([]snapshotRegex, len(data))
+	for i, d := range data {
+		out[i] = snapshotRegex{ID: d.ID, Regex: d.Regex}
+	}
+
+	return out
+}
+
+func restoreRegexes(data []snapshotRegex) ([]rexData, error) {
+	out := make([]rexData, len(data))
+	for i, d := range data {
+		out[i] = rexData{ID: d.ID, Regex: d.Regex}
+		if err := out[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func copyMap[K comparable, V any](dst, src map[K]V) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
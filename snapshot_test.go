@@ -0,0 +1,87 @@
+package udger
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testUdger() *udger {
+	u := newUdger()
+	u.rexBrowsers = []rexData{{ID: 1, Regex: `chrome\/([0-9a-z\._]+)`}}
+	u.rexOS = []rexData{{ID: 2, Regex: `windows nt 6\.1`}}
+	u.rexDevices = []rexData{}
+	for i := range u.rexBrowsers {
+		_ = u.rexBrowsers[i].compile()
+	}
+	for i := range u.rexOS {
+		_ = u.rexOS[i].compile()
+	}
+	u.browsers[1] = Browser{Family: "Chrome", Engine: "WebKit/Blink", Company: "Google Inc.", Icon: "chrome.png", typ: 1}
+	u.browserTypes[1] = "Browser"
+	u.os[2] = OS{Name: "Windows 7", Family: "Windows", Company: "Microsoft Corporation.", Icon: "windows-7.png"}
+	u.ips[netip.MustParseAddr("66.249.64.1")] = IP{IP: "66.249.64.1", ClassID: 1, CrawlerID: 3}
+	u.ipClasses[1] = IPClass{ID: 1, IPClassification: "Crawler", IPClassificationCode: "crawler"}
+	u.crawlers[3] = Crawler{ID: 3, Name: "Googlebot/2.1", Family: "Googlebot", FamilyCode: "googlebot", ClassID: 4}
+	u.crawlerClasses[4] = CrawlerClass{ID: 4, CrawlerClassification: "Search engine bot", CrawlerClassificationCode: "search_engine_bot"}
+	u.dataCenters[5] = DataCenter{ID: 5, Name: "Google sites", NameCode: "google"}
+	u.dcRanges4 = append(u.dcRanges4, newDCRange4(DataCenterRange{DatacenterID: 5, IPFrom: "66.249.64.0", IPTo: "66.249.95.255", IPLongFrom: 1123631104, IPLongTo: 1123639295}))
+
+	return u
+}
+
+func TestSnapshot(t *testing.T) {
+	Convey("write and read a snapshot", t, func() {
+		u := testUdger()
+
+		var buf bytes.Buffer
+		So(WriteSnapshot(&buf, newClient(u, nil)), ShouldBeNil)
+
+		c, err := NewFromSnapshot(bytes.NewReader(buf.Bytes()))
+		So(err, ShouldBeNil)
+		So(c, ShouldNotBeNil)
+
+		Convey("lookups match the original client", func() {
+			ua := "Mozilla/5.0 (Windows NT 6.1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2575.0 Safari/537.36"
+			want, err := u.Lookup(ua)
+			So(err, ShouldBeNil)
+			got, err := c.Lookup(ua)
+			So(err, ShouldBeNil)
+			So(got, ShouldResemble, want)
+			So(got.Browser.Type, ShouldEqual, "Browser")
+
+			ip := net.ParseIP("66.249.64.1")
+			wantIP, err := u.LookupIP(ip)
+			So(err, ShouldBeNil)
+			gotIP, err := c.LookupIP(ip)
+			So(err, ShouldBeNil)
+			So(gotIP, ShouldResemble, wantIP)
+			So(gotIP.DataCenter.NameCode, ShouldEqual, "google")
+		})
+
+		Convey("corrupted data is rejected", func() {
+			data := buf.Bytes()
+			data[len(data)/2] ^= 0xff
+
+			_, err := NewFromSnapshot(bytes.NewReader(data))
+			So(errors.Is(err, ErrInvalidSnapshot), ShouldBeTrue)
+		})
+
+		Convey("truncated data is rejected", func() {
+			_, err := NewFromSnapshot(bytes.NewReader(buf.Bytes()[:buf.Len()-10]))
+			So(errors.Is(err, ErrInvalidSnapshot), ShouldBeTrue)
+		})
+
+		Convey("other versions are rejected", func() {
+			data := buf.Bytes()
+			data[11]++
+
+			_, err := NewFromSnapshot(bytes.NewReader(data))
+			So(errors.Is(err, ErrSnapshotVersion), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,7 @@
+//go:build cgo && !udger_nocgo
+
+package udger
+
+// The cgo SQLite driver is registered by default to keep New working out of the box.
+// Build with CGO_ENABLED=0 or the udger_nocgo tag to leave it out and use WithDriver instead.
+import _ "github.com/mattn/go-sqlite3"
@@ -0,0 +1,138 @@
+package udger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// DefaultCacheSize is the number of rows cached per table in on demand mode.
+const DefaultCacheSize = 4096
+
+// sqlStore serves the rows of udger_ip_list and udger_crawler_list with indexed
+// queries instead of keeping the tables in memory. IPs are matched by their canonical
+// text form, as stored in the udger database. The few rows stored in another form
+// (zero padded, IPv4-mapped IPv6...) are found through ipAliases, which maps their
+// canonical form to the stored text, so both modes match the same rows.
+type sqlStore struct {
+	ipStmt      *sql.Stmt
+	crawlerStmt *sql.Stmt
+	ipAliases   map[string]string
+	ips         *lru[string, cachedIP]
+	crawlers    *lru[int, cachedCrawler]
+}
+
+type cachedIP struct {
+	ip    IP
+	found bool
+}
+
+type cachedCrawler struct {
+	crawler Crawler
+	found   bool
+}
+
+func newSQLStore(db *sql.DB, o *options) (*sqlStore, error) {
+	aliases, err := ipAliases(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	ipStmt, err := db.Prepare(fmt.Sprintf("SELECT ip, class_id, crawler_id, ip_last_seen, ip_hostname, ip_country, ip_city, ip_country_code FROM %s WHERE ip = ?", o.table("udger_ip_list")))
+	if err != nil {
+		return nil, err
+	}
+
+	crawlerStmt, err := db.Prepare(fmt.Sprintf("SELECT id, ua_string, ver, ver_major, class_id, last_seen, respect_robotstxt, family, family_code, family_homepage, family_icon, vendor, vendor_code, vendor_homepage, name FROM %s WHERE id = ?", o.table("udger_crawler_list")))
+	if err != nil {
+		ipStmt.Close()
+		return nil, err
+	}
+
+	return &sqlStore{
+		ipStmt:      ipStmt,
+		crawlerStmt: crawlerStmt,
+		ipAliases:   aliases,
+		ips:         newLRU[string, cachedIP](o.cacheSize),
+		crawlers:    newLRU[int, cachedCrawler](o.cacheSize),
+	}, nil
+}
+
+func (s *sqlStore) ip(key string, stats *lookupStats) (IP, bool, error) {
+	c, ok := s.ips.get(key)
+	stats.cache(ok)
+	if ok {
+		return c.ip, c.found, nil
+	}
+
+	stored := key
+	if alias, ok := s.ipAliases[key]; ok {
+		stored = alias
+	}
+
+	var ip IP
+	err := s.ipStmt.QueryRow(stored).Scan(&ip.IP, &ip.ClassID, &ip.CrawlerID, &ip.IPLastSeen, &ip.IPHostname, &ip.IPCountry, &ip.IPCity, &ip.IPCountryCode)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return IP{}, false, err
+	}
+
+	found := err == nil
+	s.ips.add(key, cachedIP{ip: ip, found: found})
+
+	return ip, found, nil
+}
+
+// ipAliases reads the IPs not stored in their canonical text form. Only the ip
+// column is scanned and only the non canonical rows are kept.
+func ipAliases(db *sql.DB, o *options) (map[string]string, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT ip FROM %s", o.table("udger_ip_list")))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var stored string
+		if err := rows.Scan(&stored); err != nil {
+			return nil, err
+		}
+		addr, err := ParseAddr(stored)
+		if err != nil {
+			continue
+		}
+		if canonical := addr.String(); canonical != stored {
+			aliases[canonical] = stored
+		}
+	}
+
+	return aliases, rows.Err()
+}
+
+func (s *sqlStore) crawler(id int, stats *lookupStats) (Crawler, bool, error) {
+	cached, ok := s.crawlers.get(id)
+	stats.cache(ok)
+	if ok {
+		return cached.crawler, cached.found, nil
+	}
+
+	var c Crawler
+	err := s.crawlerStmt.QueryRow(id).Scan(&c.ID, &c.UA, &c.Ver, &c.VerMajor, &c.ClassID, &c.LastSeen, &c.RespectRobotstxt, &c.Family, &c.FamilyCode, &c.FamilyHomepage, &c.FamilyIcon, &c.Vendor, &c.VendorCode, &c.VendorHomepage, &c.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Crawler{}, false, err
+	}
+
+	found := err == nil
+	s.crawlers.add(id, cachedCrawler{crawler: c, found: found})
+
+	return c, found, nil
+}
+
+func (s *sqlStore) close() error {
+	err := s.ipStmt.Close()
+	if cerr := s.crawlerStmt.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
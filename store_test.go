@@ -0,0 +1,101 @@
+package udger_test
+
+import (
+	"database/sql"
+	"net"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOnDemand(t *testing.T) {
+	Convey("load in on demand mode", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithOnDemand(udger.DefaultCacheSize))
+		So(err, ShouldBeNil)
+
+		Convey("IPs and crawlers are served from the database", func() {
+			info, err := u.LookupIP(net.ParseIP("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(info.IP.IPHostname, ShouldEqual, "crawl-66-249-64-1.googlebot.com")
+			So(info.IPClass.IPClassificationCode, ShouldEqual, "crawler")
+			So(info.Crawler.FamilyCode, ShouldEqual, "googlebot")
+			So(info.CrawlerClass.CrawlerClassificationCode, ShouldEqual, "search_engine_bot")
+
+			info, err = u.LookupIP(net.ParseIP("10.0.0.1"))
+			So(err, ShouldBeNil)
+			So(info.IP, ShouldResemble, udger.IP{})
+			So(info.Crawler, ShouldResemble, udger.Crawler{})
+		})
+
+		Convey("regexes are kept in memory", func() {
+			info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "IE")
+		})
+
+		Convey("cached rows survive the close", func() {
+			_, err := u.LookupIP(net.ParseIP("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(u.Close(), ShouldBeNil)
+
+			info, err := u.LookupIP(net.ParseIP("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(info.Crawler.FamilyCode, ShouldEqual, "googlebot")
+			So(info.CacheHit, ShouldBeTrue)
+
+			_, err = u.LookupIP(net.ParseIP("66.249.64.2"))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("cannot be exported to a snapshot", func() {
+			So(udger.WriteSnapshot(nil, u), ShouldEqual, udger.ErrSnapshotUnsupported)
+		})
+	})
+
+	Convey("non canonical IPs match the same rows in both modes", t, func() {
+		d := udgertest.Default()
+		d.IPs = append(d.IPs,
+			udger.IP{IP: "2001:4860:0000:0000:0000:0000:0000:0001", ClassID: 1, CrawlerID: 3, IPHostname: "padded"},
+			udger.IP{IP: "::ffff:66.249.64.3", ClassID: 1, CrawlerID: 3, IPHostname: "mapped"},
+			udger.IP{IP: "2001:4860::ABCD", ClassID: 1, CrawlerID: 3, IPHostname: "upper"},
+		)
+		path := udgertest.Create(t, d)
+
+		for name, opts := range map[string][]udger.Option{
+			"in memory": {udger.WithDriver(udgertest.Driver)},
+			"on demand": {udger.WithDriver(udgertest.Driver), udger.WithOnDemand(udger.DefaultCacheSize)},
+		} {
+			opts := opts
+			Convey(name, func() {
+				u, err := udger.New(path, opts...)
+				So(err, ShouldBeNil)
+				defer u.Close()
+
+				for ip, host := range map[string]string{
+					"2001:4860::1":       "padded",
+					"66.249.64.3":        "mapped",
+					"::ffff:66.249.64.3": "mapped",
+					"2001:4860::abcd":    "upper",
+				} {
+					info, err := u.LookupIP(net.ParseIP(ip))
+					So(err, ShouldBeNil)
+					So(info.IP.IPHostname, ShouldEqual, host)
+					So(info.Crawler.FamilyCode, ShouldEqual, "googlebot")
+				}
+			})
+		}
+	})
+
+	Convey("close keeps a caller owned handle open", t, func() {
+		db, err := sql.Open(udgertest.Driver, createTestDB(t))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		u, err := udger.NewFromDB(db, udger.WithOnDemand(0))
+		So(err, ShouldBeNil)
+		So(u.Close(), ShouldBeNil)
+		So(db.Ping(), ShouldBeNil)
+	})
+}
@@ -3,54 +3,89 @@ package udger
 import (
 	"database/sql"
 	"net"
+	"net/netip"
 	"regexp"
-
-	_ "github.com/mattn/go-sqlite3"
+	"sync"
 )
 
+// Client looks up user agents and IPs in the udger database.
+// It is safe for concurrent use, including with the reloads of the database.
 type Client interface {
 	// Lookup gathers information about the client using the provided user agent
 	Lookup(ua string) (*Info, error)
 	// LookupIP gathers information about the client using the provided IP
 	LookupIP(ip net.IP) (*IPInfo, error)
+	// LookupAddr gathers information about the client using the provided address
+	LookupAddr(addr netip.Addr) (*IPInfo, error)
+	// Close releases the resources held by the client
+	Close() error
+}
+
+// IntoLookuper is implemented by the clients able to fill an Info in place, to look user agents up
+// without allocating a result.
+type IntoLookuper interface {
+	// LookupInto gathers information about the client using the provided user agent into dst, overwriting it
+	LookupInto(ua string, dst *Info) error
 }
 
+// udger is a loaded database. It is not modified once loaded, Reload swaps it for a new one.
 type udger struct {
-	db               *sql.DB
-	rexBrowsers      []rexData
-	rexDevices       []rexData
-	rexOS            []rexData
-	browserTypes     map[int]string
-	browserOS        map[int]int
-	Browsers         map[int]Browser
-	OS               map[int]OS
-	Devices          map[int]Device
-	IP               map[string]IP
-	IPClass          map[int]IPClass
-	Crawler          map[int]Crawler
-	CrawlerClass     map[int]CrawlerClass
-	DataCenter       map[int]DataCenter
-	DataCenterRange  []DataCenterRange
-	DataCenterRange6 []DataCenterRange6
+	db             *sql.DB
+	opts           *options
+	store          *sqlStore
+	ownsDB         bool
+	dbVersion      string
+	rexBrowsers    []rexData
+	rexDevices     []rexData
+	rexOS          []rexData
+	browserTypes   map[int]string
+	browserClasses map[int]ClientClass
+	classDevices   map[int]int
+	browserOS      map[int]int
+	browsers       map[int]Browser
+	os             map[int]OS
+	devices        map[int]Device
+	ips            map[netip.Addr]IP
+	skippedIPs     int
+	ipClasses      map[int]IPClass
+	crawlers       map[int]Crawler
+	crawlerUAs     map[string]int
+	crawlerClasses map[int]CrawlerClass
+	dataCenters    map[int]DataCenter
+	dcRanges4      []dcRange4
+	dcRanges6      []dcRange6
+	names          *nameTable
+	uaCache        *lru[string, Info]
+	// mu keeps the store open during the lookups, in on demand mode
+	mu     sync.RWMutex
+	closed bool
 }
 
 // Info is the struct returned by the Lookup(ua string) function, contains everything about the UA
 type Info struct {
-	Browser Browser `json:"browser"`
-	OS      OS      `json:"os"`
-	Device  Device  `json:"device"`
+	Browser    Browser    `json:"browser"`
+	OS         OS         `json:"os"`
+	Device     Device     `json:"device"`
+	Recognized Recognized `json:"recognized"`
+	// DeviceSource tells how the device was determined
+	DeviceSource DeviceSource `json:"device_source"`
+	// CacheHit reports that the result was served by the lookup cache
+	CacheHit bool `json:"cache_hit"`
 }
 
 // Browser contains information about the browser type, engine and off course it's name
 type Browser struct {
+	ID      int    `json:"id"`
 	Name    string `json:"name"`
 	Family  string `json:"family"`
 	Version string `json:"version"`
 	Engine  string `json:"engine"`
 	typ     int
 	Type    string `json:"type"`
-	Company string `json:"company"`
-	Icon    string `json:"icon"`
+	// Class is the code of the client class, Type is its name
+	Class   ClientClass `json:"class"`
+	Company string      `json:"company"`
+	Icon    string      `json:"icon"`
 }
 
 type rexData struct {
@@ -61,16 +96,20 @@ type rexData struct {
 
 // OS contains all the information about the operating system
 type OS struct {
-	Name    string `json:"name"`
-	Family  string `json:"family"`
-	Icon    string `json:"icon"`
-	Company string `json:"company"`
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Family     string `json:"family"`
+	FamilyCode string `json:"family_code"`
+	Icon       string `json:"icon"`
+	Company    string `json:"company"`
 }
 
 // Device contains all the information about the device type
 type Device struct {
-	Name string `json:"name"`
-	Icon string `json:"icon"`
+	ID    int         `json:"id"`
+	Name  string      `json:"name"`
+	Class DeviceClass `json:"class"`
+	Icon  string      `json:"icon"`
 }
 
 type IPInfo struct {
@@ -81,6 +120,8 @@ type IPInfo struct {
 	DataCenter       DataCenter       `json:"data_center"`
 	DataCenterRange  DataCenterRange  `json:"data_center_range"`
 	DataCenterRange6 DataCenterRange6 `json:"data_center_range6"`
+	// CacheHit reports that the rows were served by the cache of the on demand mode
+	CacheHit bool `json:"cache_hit"`
 }
 
 // Device contains all the information about the device type
@@ -0,0 +1,286 @@
+// Package uap implements udger.Client with the open ua-parser regexes (uap-core regexes.yaml),
+// for the environments without an Udger database. The IP lookups return empty results.
+package uap
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/msales/udger"
+	"gopkg.in/yaml.v3"
+)
+
+// Parsers are the parsers of a regexes.yaml file.
+type Parsers struct {
+	UserAgent []Parser `yaml:"user_agent_parsers"`
+	OS        []Parser `yaml:"os_parsers"`
+	Device    []Parser `yaml:"device_parsers"`
+}
+
+// Parser is a rule of a regexes.yaml file. The replacements can reference the groups of the regex
+// with $1 to $9, the missing replacements default to the groups of the regex in order.
+type Parser struct {
+	Regex     string `yaml:"regex"`
+	RegexFlag string `yaml:"regex_flag"`
+
+	FamilyReplacement string `yaml:"family_replacement"`
+	V1Replacement     string `yaml:"v1_replacement"`
+	V2Replacement     string `yaml:"v2_replacement"`
+	V3Replacement     string `yaml:"v3_replacement"`
+
+	OSReplacement   string `yaml:"os_replacement"`
+	OSV1Replacement string `yaml:"os_v1_replacement"`
+	OSV2Replacement string `yaml:"os_v2_replacement"`
+	OSV3Replacement string `yaml:"os_v3_replacement"`
+	OSV4Replacement string `yaml:"os_v4_replacement"`
+
+	DeviceReplacement string `yaml:"device_replacement"`
+	BrandReplacement  string `yaml:"brand_replacement"`
+	ModelReplacement  string `yaml:"model_replacement"`
+}
+
+type rule struct {
+	rex       *regexp.Regexp
+	templates []string
+}
+
+// apply returns the fields of the rule for the user agent, or false when the rule does not match.
+func (r *rule) apply(ua string) ([]string, bool) {
+	m := r.rex.FindStringSubmatch(ua)
+	if m == nil {
+		return nil, false
+	}
+
+	fields := make([]string, len(r.templates))
+	for i, t := range r.templates {
+		fields[i] = strings.TrimSpace(groupRegex.ReplaceAllStringFunc(t, func(g string) string {
+			n, _ := strconv.Atoi(g[1:])
+			if n < len(m) {
+				return m[n]
+			}
+			return ""
+		}))
+	}
+
+	return fields, true
+}
+
+var groupRegex = regexp.MustCompile(`\$[1-9]`)
+
+type client struct {
+	browsers []rule
+	os       []rule
+	devices  []rule
+}
+
+// New loads a regexes.yaml file.
+func New(path string) (udger.Client, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read loads the content of a regexes.yaml file.
+func Read(r io.Reader) (udger.Client, error) {
+	var p Parsers
+	if err := yaml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("uap: invalid regexes: %w", err)
+	}
+
+	return NewFromParsers(p)
+}
+
+// NewFromParsers compiles the parsers.
+func NewFromParsers(p Parsers) (udger.Client, error) {
+	c := &client{}
+
+	var err error
+	if c.browsers, err = compile("user_agent_parsers", p.UserAgent, func(p Parser) []string {
+		return []string{
+			or(p.FamilyReplacement, "$1"),
+			or(p.V1Replacement, "$2"),
+			or(p.V2Replacement, "$3"),
+			or(p.V3Replacement, "$4"),
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if c.os, err = compile("os_parsers", p.OS, func(p Parser) []string {
+		return []string{
+			or(p.OSReplacement, "$1"),
+			or(p.OSV1Replacement, "$2"),
+			or(p.OSV2Replacement, "$3"),
+			or(p.OSV3Replacement, "$4"),
+			or(p.OSV4Replacement, "$5"),
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if c.devices, err = compile("device_parsers", p.Device, func(p Parser) []string {
+		// the brand has no default group
+		return []string{or(p.DeviceReplacement, "$1"), p.BrandReplacement, or(p.ModelReplacement, "$1")}
+	}); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func or(replacement, group string) string {
+	if replacement == "" {
+		return group
+	}
+
+	return replacement
+}
+
+func compile(name string, parsers []Parser, templates func(Parser) []string) ([]rule, error) {
+	rules := make([]rule, 0, len(parsers))
+	for i, p := range parsers {
+		expr := p.Regex
+		if strings.Contains(p.RegexFlag, "i") {
+			expr = "(?i)" + expr
+		}
+		rex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("uap: %s %d: %w", name, i, err)
+		}
+		rules = append(rules, rule{rex: rex, templates: templates(p)})
+	}
+
+	return rules, nil
+}
+
+func match(rules []rule, ua string) []string {
+	for i := range rules {
+		if fields, ok := rules[i].apply(ua); ok {
+			return fields
+		}
+	}
+
+	return nil
+}
+
+// Lookup gathers information about the client using the provided user agent. The client types
+// and device classes are not part of the uap regexes, they are derived from the device model,
+// the OS and the mobile token of the user agent. Devices without any mobile signal, such as
+// the Mac, are personal computers.
+func (c *client) Lookup(ua string) (*udger.Info, error) {
+	info := &udger.Info{}
+
+	if f := match(c.browsers, ua); f != nil && f[0] != "" {
+		info.Browser.Family = f[0]
+		info.Recognized.Browser = true
+		info.Browser.Version = version(f[1:]...)
+		info.Browser.Name = strings.TrimSpace(info.Browser.Family + " " + info.Browser.Version)
+	}
+
+	if f := match(c.os, ua); f != nil && f[0] != "" {
+		info.OS.Family = f[0]
+		info.Recognized.OS = true
+		info.OS.Name = strings.TrimSpace(f[0] + " " + version(f[1:]...))
+	}
+
+	var device, model string
+	if f := match(c.devices, ua); f != nil {
+		device, model = f[0], f[2]
+		info.Recognized.Device = device != "" && device != "Other"
+	}
+	info.DeviceSource = udger.DeviceSourceGuess
+	if info.Recognized.Device {
+		info.DeviceSource = udger.DeviceSourceRegex
+	}
+
+	class, mapped := modelClasses[model]
+	if !mapped {
+		class, mapped = modelClasses[device]
+	}
+	switch {
+	case device == "Spider":
+		info.Browser.Type, info.Browser.Class = "Crawler", udger.ClientClassCrawler
+		class = udger.DeviceClassOther
+	case tabletRegex.MatchString(device) || tabletRegex.MatchString(model):
+		class = udger.DeviceClassTablet
+	case mapped:
+		// the class of the model is kept
+	case mobileOS[info.OS.Family] || mobileRegex.MatchString(ua):
+		class = udger.DeviceClassSmartphone
+	default:
+		class = udger.DeviceClassDesktop
+	}
+	info.Device = devices[class]
+	if info.Browser.Family != "" && info.Browser.Type == "" {
+		info.Browser.Type, info.Browser.Class = "Browser", udger.ClientClassBrowser
+		if info.Device.Class != udger.DeviceClassDesktop {
+			info.Browser.Type, info.Browser.Class = "Mobile browser", udger.ClientClassMobileBrowser
+		}
+	}
+
+	return info, nil
+}
+
+var tabletRegex = regexp.MustCompile(`(?i)ipad|tablet|\btab\b|kindle|playbook|nexus (7|9|10)\b`)
+
+var mobileRegex = regexp.MustCompile(`Mobi`)
+
+// modelClasses maps the uap device models and names whose class is known.
+var modelClasses = map[string]udger.DeviceClass{
+	"Mac":                   udger.DeviceClassDesktop,
+	"Generic Smartphone":    udger.DeviceClassSmartphone,
+	"Generic Feature Phone": udger.DeviceClassSmartphone,
+	"Generic Tablet":        udger.DeviceClassTablet,
+}
+
+var devices = map[udger.DeviceClass]udger.Device{
+	udger.DeviceClassDesktop:    {Name: "Personal computer", Class: udger.DeviceClassDesktop, Icon: "desktop.png"},
+	udger.DeviceClassSmartphone: {Name: "Smartphone", Class: udger.DeviceClassSmartphone, Icon: "phone.png"},
+	udger.DeviceClassTablet:     {Name: "Tablet", Class: udger.DeviceClassTablet, Icon: "tablet.png"},
+	udger.DeviceClassOther:      {Name: "Other", Class: udger.DeviceClassOther, Icon: "other.png"},
+}
+
+var mobileOS = map[string]bool{
+	"Android":       true,
+	"iOS":           true,
+	"Windows Phone": true,
+	"BlackBerry OS": true,
+	"KaiOS":         true,
+	"Tizen":         true,
+}
+
+// version joins the non empty parts of a version, stopping at the first empty part.
+func version(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p == "" {
+			break
+		}
+		out = append(out, p)
+	}
+
+	return strings.Join(out, ".")
+}
+
+// LookupIP returns an empty result, the uap regexes have no IP data.
+func (c *client) LookupIP(ip net.IP) (*udger.IPInfo, error) {
+	return &udger.IPInfo{}, nil
+}
+
+// LookupAddr returns an empty result, the uap regexes have no IP data.
+func (c *client) LookupAddr(addr netip.Addr) (*udger.IPInfo, error) {
+	return &udger.IPInfo{}, nil
+}
+
+// Close does nothing, the regexes are held in memory.
+func (c *client) Close() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package uap_test
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/uap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testRegexes = `
+user_agent_parsers:
+  - regex: '(bingbot)/(\d+)\.(\d+)'
+    family_replacement: 'bingbot'
+  - regex: '(CriOS)/(\d+)\.(\d+)\.(\d+)'
+    family_replacement: 'Chrome Mobile iOS'
+  - regex: '(Chrome)/(\d+)\.(\d+)\.(\d+)'
+  - regex: '(MSIE) (\d+)\.(\d+)'
+    family_replacement: 'IE'
+os_parsers:
+  - regex: '(Windows NT 6\.1)'
+    os_replacement: 'Windows'
+    os_v1_replacement: '7'
+  - regex: '(CPU OS|iPhone OS) (\d+)_(\d+)'
+    os_replacement: 'iOS'
+  - regex: '(Android)[ \-/](\d+)(?:\.(\d+))?'
+  - regex: '(Mac OS X) (\d+)[_.](\d+)'
+device_parsers:
+  - regex: '(?:bingbot)'
+    regex_flag: 'i'
+    device_replacement: 'Spider'
+    brand_replacement: 'Spider'
+    model_replacement: 'Desktop'
+  - regex: '(iPad);'
+    brand_replacement: 'Apple'
+  - regex: '; *(SM-G\d+\w*) Build'
+    device_replacement: 'Samsung $1'
+    brand_replacement: 'Samsung'
+  - regex: 'Macintosh;'
+    device_replacement: 'Mac'
+    brand_replacement: 'Apple'
+    model_replacement: 'Mac'
+`
+
+func TestUAP(t *testing.T) {
+	Convey("lookup with the uap regexes", t, func() {
+		u, err := uap.Read(strings.NewReader(testRegexes))
+		So(err, ShouldBeNil)
+		defer u.Close()
+
+		Convey("a desktop browser", func() {
+			info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "IE")
+			So(info.Browser.Version, ShouldEqual, "8.0")
+			So(info.Browser.Name, ShouldEqual, "IE 8.0")
+			So(info.Browser.Type, ShouldEqual, "Browser")
+			So(info.OS.Family, ShouldEqual, "Windows")
+			So(info.OS.Name, ShouldEqual, "Windows 7")
+			So(info.Device.Name, ShouldEqual, "Personal computer")
+		})
+
+		Convey("a browser on macOS", func() {
+			info, err := u.Lookup("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36")
+			So(err, ShouldBeNil)
+			So(info.Browser.Type, ShouldEqual, "Browser")
+			So(info.OS.Name, ShouldEqual, "Mac OS X 10.15")
+			So(info.Device.Name, ShouldEqual, "Personal computer")
+			So(info.Device.Class, ShouldEqual, udger.DeviceClassDesktop)
+			So(info.Recognized.Device, ShouldBeTrue)
+		})
+
+		Convey("a smartphone", func() {
+			info, err := u.Lookup("Mozilla/5.0 (Linux; Android 4.4.2; SM-G900F Build/KOT49H) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2575.0 Mobile Safari/537.36")
+			So(err, ShouldBeNil)
+			So(info.Browser.Name, ShouldEqual, "Chrome 49.0.2575")
+			So(info.Browser.Type, ShouldEqual, "Mobile browser")
+			So(info.OS.Name, ShouldEqual, "Android 4.4")
+			So(info.Device.Name, ShouldEqual, "Smartphone")
+		})
+
+		Convey("a tablet", func() {
+			info, err := u.Lookup("Mozilla/5.0 (iPad; CPU OS 9_3 like Mac OS X) AppleWebKit/601.1 (KHTML, like Gecko) CriOS/49.0.2623.109 Mobile/13E238 Safari/601.1.46")
+			So(err, ShouldBeNil)
+			So(info.Browser.Name, ShouldEqual, "Chrome Mobile iOS 49.0.2623")
+			So(info.OS.Name, ShouldEqual, "iOS 9.3")
+			So(info.Device.Name, ShouldEqual, "Tablet")
+			So(info.Recognized, ShouldResemble, udger.Recognized{Browser: true, OS: true, Device: true})
+			So(info.DeviceSource, ShouldEqual, udger.DeviceSourceRegex)
+		})
+
+		Convey("a crawler", func() {
+			info, err := u.Lookup("Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "bingbot")
+			So(info.Browser.Type, ShouldEqual, "Crawler")
+			So(info.Device.Name, ShouldEqual, "Other")
+		})
+
+		Convey("an unknown user agent", func() {
+			info, err := u.Lookup("unknown")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldBeEmpty)
+			So(info.Browser.Type, ShouldBeEmpty)
+			So(info.OS.Family, ShouldBeEmpty)
+			So(info.Recognized, ShouldResemble, udger.Recognized{})
+			So(info.DeviceSource, ShouldEqual, udger.DeviceSourceGuess)
+		})
+
+		Convey("no IP data", func() {
+			info, err := u.LookupAddr(netip.MustParseAddr("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(info.IP.IP, ShouldBeEmpty)
+		})
+	})
+
+	Convey("reject invalid regexes", t, func() {
+		_, err := uap.Read(strings.NewReader("user_agent_parsers:\n  - regex: '(a'\n"))
+		So(err, ShouldNotBeNil)
+	})
+}
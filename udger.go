@@ -2,165 +2,337 @@
 package udger
 
 import (
-	"bytes"
 	"database/sql"
 	"encoding/binary"
+	"fmt"
 	"net"
+	"net/netip"
 	"os"
 	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // New creates a new instance of Udger and load all the database in memory to allow fast lookup
-// you need to pass the sqlite database in parameter
-func New(dbPath string) (Client, error) {
-	u := &udger{
-		Browsers:         make(map[int]Browser),
-		OS:               make(map[int]OS),
-		Devices:          make(map[int]Device),
-		IP:               make(map[string]IP),
-		IPClass:          make(map[int]IPClass),
-		Crawler:          make(map[int]Crawler),
-		CrawlerClass:     make(map[int]CrawlerClass),
-		DataCenter:       make(map[int]DataCenter),
-		DataCenterRange:  make([]DataCenterRange, 0),
-		DataCenterRange6: make([]DataCenterRange6, 0),
-		browserTypes:     make(map[int]string),
-		browserOS:        make(map[int]int),
-	}
-	var err error
+// you need to pass the sqlite database in parameter. The client implements Reloader, which loads dbPath again.
+func New(dbPath string, opts ...Option) (Client, error) {
+	o := newOptions(opts)
+	load := func() (*udger, error) {
+		return newFromPath(dbPath, o)
+	}
+	start := time.Now()
+	u, err := load()
+	o.observeLoad(start, u, false, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(u, load), nil
+}
 
+func newFromPath(dbPath string, o *options) (*udger, error) {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return nil, err
 	}
 
-	u.db, err = sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(o.driver, dbPath)
 	if err != nil {
 		return nil, err
 	}
-	defer u.db.Close()
 
-	err = u.init()
+	u, err := newFromDB(db, o)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	if u.store == nil {
+		db.Close()
+	} else {
+		u.ownsDB = true
+	}
 
 	return u, nil
 }
 
+// NewFromDB creates a new instance of Udger and load all the database in memory from an already open handle.
+// The handle is owned by the caller and is not closed, not even by Close. Reload reads it again.
+func NewFromDB(db *sql.DB, opts ...Option) (Client, error) {
+	o := newOptions(opts)
+	load := func() (*udger, error) {
+		return newFromDB(db, o)
+	}
+	start := time.Now()
+	u, err := load()
+	o.observeLoad(start, u, false, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(u, load), nil
+}
+
+func newFromDB(db *sql.DB, o *options) (*udger, error) {
+	u := newUdger()
+	u.db = db
+	u.opts = o
+
+	if err := u.init(); err != nil {
+		return nil, err
+	}
+	u.dbVersion = u.version()
+	if err := u.applyRules(o.rules); err != nil {
+		return nil, err
+	}
+
+	u.uaCache = newLookupCache(o)
+
+	if o.onDemand {
+		store, err := newSQLStore(db, o)
+		if err != nil {
+			return nil, err
+		}
+		u.store = store
+	}
+
+	return u, nil
+}
+
+func newUdger() *udger {
+	return &udger{
+		opts:           newOptions(nil),
+		browsers:       make(map[int]Browser),
+		os:             make(map[int]OS),
+		devices:        make(map[int]Device),
+		ips:            make(map[netip.Addr]IP),
+		ipClasses:      make(map[int]IPClass),
+		crawlers:       make(map[int]Crawler),
+		crawlerUAs:     make(map[string]int),
+		crawlerClasses: make(map[int]CrawlerClass),
+		dataCenters:    make(map[int]DataCenter),
+		browserTypes:   make(map[int]string),
+		browserClasses: make(map[int]ClientClass),
+		classDevices:   make(map[int]int),
+		browserOS:      make(map[int]int),
+		names:          &nameTable{names: make(map[nameKey]string)},
+	}
+}
+
 // Lookup one user agent and return a Info struct who contains all the metadata possible for the UA.
 func (u *udger) Lookup(ua string) (*Info, error) {
 	info := &Info{}
-
-	browserID, version, err := u.findDataWithVersion(ua, u.rexBrowsers, true)
-	if err != nil {
+	if err := u.LookupInto(ua, info); err != nil {
 		return nil, err
 	}
 
-	info.Browser = u.Browsers[browserID]
-	if info.Browser.Family != "" {
-		info.Browser.Name = info.Browser.Family + " " + version
+	return info, nil
+}
+
+// LookupInto gathers information about the client using the provided user agent into dst.
+// It does not allocate, unless the browser versions are extracted with WithBrowserVersion.
+func (u *udger) LookupInto(ua string, dst *Info) error {
+	if u.opts.observer == nil {
+		return u.lookupInto(ua, dst, nil)
 	}
-	info.Browser.Version = version
-	info.Browser.Type = u.browserTypes[info.Browser.typ]
 
-	if val, ok := u.browserOS[browserID]; ok {
-		info.OS = u.OS[val]
+	start := time.Now()
+	var stats lookupStats
+	err := u.lookupInto(ua, dst, &stats)
+	u.observeLookup(LookupTypeUA, start, &stats, err == nil && dst.unmatched(), err)
+
+	return err
+}
+
+func (u *udger) lookupInto(ua string, dst *Info, stats *lookupStats) error {
+	var hit bool
+	cached := u.uaCache != nil && len(ua) <= maxCachedUALength
+	if cached {
+		*dst, hit = u.uaCache.get(ua)
+		stats.cache(hit)
+	}
+	if hit {
+		dst.CacheHit = true
 	} else {
-		osID, _, err := u.findData(ua, u.rexOS, false)
-		if err != nil {
-			return nil, err
+		*dst = Info{}
+		if err := u.lookup(ua, dst, stats); err != nil {
+			*dst = Info{}
+			return err
+		}
+		if cached {
+			// copy the key, it may share the memory of a larger string of the caller
+			u.uaCache.add(strings.Clone(ua), *dst)
 		}
-		info.OS = u.OS[osID]
 	}
 
-	deviceID, _, err := u.findData(ua, u.rexDevices, false)
+	if u.opts.unmatched != nil && dst.unmatched() {
+		u.opts.unmatched(ua, dst.Recognized)
+	}
+
+	return nil
+}
+
+func (u *udger) lookup(ua string, dst *Info, stats *lookupStats) error {
+	crawler, err := u.lookupCrawler(ua, dst, stats)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if val, ok := u.Devices[deviceID]; ok {
-		info.Device = val
-	} else if info.Browser.typ == 3 { // if browser is mobile, we can guess its a mobile
-		info.Device = Device{
-			Name: "Smartphone",
-			Icon: "phone.png",
+
+	browserID := -1
+	if !crawler {
+		var version string
+		browserID, version, err = u.findDataWithVersion(ua, u.rexBrowsers, u.opts.versions, stats)
+		if err != nil {
+			return err
 		}
-	} else if info.Browser.typ == 5 || info.Browser.typ == 10 || info.Browser.typ == 20 || info.Browser.typ == 50 {
-		info.Device = Device{
-			Name: "Other",
-			Icon: "other.png",
+
+		dst.Browser, dst.Recognized.Browser = u.browsers[browserID]
+		if dst.Browser.Family != "" {
+			dst.Browser.Name = u.names.name(browserID, dst.Browser.Family, version)
 		}
+		dst.Browser.Version = version
+		dst.Browser.Type = u.browserTypes[dst.Browser.typ]
+		dst.Browser.Class = u.browserClasses[dst.Browser.typ]
+	}
+
+	if val, ok := u.browserOS[browserID]; ok {
+		dst.OS, dst.Recognized.OS = u.os[val]
 	} else {
-		//nothing so personal computer
-		info.Device = Device{
-			Name: "Personal computer",
-			Icon: "desktop.png",
+		osID, _, err := u.findData(ua, u.rexOS, false, stats)
+		if err != nil {
+			return err
 		}
+		dst.OS, dst.Recognized.OS = u.os[osID]
 	}
 
-	return info, nil
+	deviceID, _, err := u.findData(ua, u.rexDevices, false, stats)
+	if err != nil {
+		return err
+	}
+	if val, ok := u.devices[deviceID]; ok {
+		dst.Device = val
+		dst.Recognized.Device = true
+		dst.DeviceSource = DeviceSourceRegex
+	} else {
+		dst.Device, dst.DeviceSource = u.fallbackDevice(dst)
+	}
+
+	return nil
+}
+
+// crawlerClientClassID is the client class of the crawlers in udger_client_class, as in the reference parsers.
+const crawlerClientClassID = 99
+
+// lookupCrawler fills the client of dst from the crawler whose user agent is exactly ua, like the reference
+// parsers do before evaluating the client regexes. The crawler is the client, its version comes from the list.
+func (u *udger) lookupCrawler(ua string, dst *Info, stats *lookupStats) (bool, error) {
+	id, ok := u.crawlerUAs[ua]
+	if !ok {
+		return false, nil
+	}
+	c, ok, err := u.crawlerRow(id, stats)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	dst.Browser = Browser{
+		Name:    c.Name,
+		Family:  c.Family,
+		Version: c.Ver,
+		typ:     crawlerClientClassID,
+		Type:    "Crawler",
+		Class:   ClientClassCrawler,
+		Company: c.Vendor,
+		Icon:    c.FamilyIcon,
+	}
+	if t, ok := u.browserTypes[crawlerClientClassID]; ok {
+		dst.Browser.Type, dst.Browser.Class = t, u.browserClasses[crawlerClientClassID]
+	}
+	dst.Recognized.Browser = true
+
+	return true, nil
 }
 
+// LookupIP gathers information about the client using the provided IP.
 func (u *udger) LookupIP(ip net.IP) (*IPInfo, error) {
-	info := &IPInfo{}
+	addr, ok := addrFromIP(ip)
+	if !ok {
+		return &IPInfo{}, nil
+	}
 
-	var ipVersion byte
-	if ip.To4() == nil {
-		ipVersion = 6
-	} else {
-		ipVersion = 4
+	return u.LookupAddr(addr)
+}
+
+// LookupAddr gathers information about the client using the provided address, it is canonicalized first.
+func (u *udger) LookupAddr(addr netip.Addr) (*IPInfo, error) {
+	var start time.Time
+	if u.opts.observer != nil {
+		start = time.Now()
+	}
+
+	var stats lookupStats
+	info, err := u.lookupAddr(addr, &stats)
+	if err == nil {
+		info.CacheHit = stats.cacheHits > 0 && stats.cacheMisses == 0
+	}
+	if u.opts.observer != nil {
+		u.observeLookup(LookupTypeIP, start, &stats, false, err)
+	}
+
+	return info, err
+}
+
+func (u *udger) lookupAddr(addr netip.Addr, stats *lookupStats) (*IPInfo, error) {
+	info := &IPInfo{}
+	if !addr.IsValid() {
+		return info, nil
 	}
+	addr = CanonicalAddr(addr)
 
-	uIP, ok := u.IP[ip.String()]
+	uIP, ok, err := u.ipRow(addr, stats)
+	if err != nil {
+		return nil, err
+	}
 	if ok {
 		info.IP = uIP
-		uIPClass, classok := u.IPClass[uIP.ClassID]
+		uIPClass, classok := u.ipClasses[uIP.ClassID]
 		if classok {
 			info.IPClass = uIPClass
 		}
-		uCrawler, crawlerok := u.Crawler[uIP.CrawlerID]
+		uCrawler, crawlerok, err := u.crawlerRow(uIP.CrawlerID, stats)
+		if err != nil {
+			return nil, err
+		}
 		if crawlerok {
 			info.Crawler = uCrawler
-			uCrawlerClass, crawlerclassok := u.CrawlerClass[uCrawler.ClassID]
+			uCrawlerClass, crawlerclassok := u.crawlerClasses[uCrawler.ClassID]
 			if crawlerclassok {
 				info.CrawlerClass = uCrawlerClass
 			}
 		}
 	}
 
-	if ipVersion == 4 {
-		ip4 := ip.To4()
-
-		if ip4 != nil {
-			ipInt := int(binary.BigEndian.Uint32(ip4))
-			for _, dcr := range u.DataCenterRange {
-				if ipInt >= dcr.IPLongFrom && ipInt <= dcr.IPLongTo {
-					info.DataCenterRange = dcr
-					dc, ok := u.DataCenter[dcr.DatacenterID]
-					if ok {
-						info.DataCenter = dc
-					}
-					break
+	if addr.Is4() {
+		ipInt := binary.BigEndian.Uint32(addr.AsSlice())
+		for _, dcr := range u.dcRanges4 {
+			if dcr.contains(ipInt) {
+				info.DataCenterRange = dcr.expand()
+				dc, ok := u.dataCenters[int(dcr.DataCenterID)]
+				if ok {
+					info.DataCenter = dc
 				}
+				break
 			}
 		}
 	} else {
-		ip16 := ip.To16()
-
-		if ip16 != nil {
-			for _, dcr := range u.DataCenterRange6 {
-				from16 := net.ParseIP(dcr.IPFrom).To16()
-				to16 := net.ParseIP(dcr.IPTo).To16()
-				if from16 != nil && to16 != nil {
-					if bytes.Compare(ip16, from16) >= 0 && bytes.Compare(ip16, to16) <= 0 {
-						info.DataCenterRange6 = dcr
-						dc, ok := u.DataCenter[dcr.DatacenterID]
-						if ok {
-							info.DataCenter = dc
-						}
-						break
-					}
+		ip16 := addr.As16()
+		for _, dcr := range u.dcRanges6 {
+			if dcr.contains(ip16) {
+				info.DataCenterRange6 = dcr.expand()
+				dc, ok := u.dataCenters[int(dcr.DataCenterID)]
+				if ok {
+					info.DataCenter = dc
 				}
+				break
 			}
 		}
 	}
@@ -168,6 +340,48 @@ func (u *udger) LookupIP(ip net.IP) (*IPInfo, error) {
 	return info, nil
 }
 
+// Close releases the database handle kept open in on demand mode.
+// It waits for the lookups in progress, which hold the read lock.
+func (u *udger) Close() error {
+	if u.store == nil {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+
+	err := u.store.close()
+	if u.ownsDB {
+		if cerr := u.db.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+func (u *udger) ipRow(addr netip.Addr, stats *lookupStats) (IP, bool, error) {
+	if u.store != nil {
+		return u.store.ip(addr.String(), stats)
+	}
+
+	ip, ok := u.ips[addr]
+	return ip, ok, nil
+}
+
+func (u *udger) crawlerRow(id int, stats *lookupStats) (Crawler, bool, error) {
+	if u.store != nil {
+		return u.store.crawler(id, stats)
+	}
+
+	c, ok := u.crawlers[id]
+	return c, ok, nil
+}
+
 func (u *udger) cleanRegex(r string) string {
 	if strings.HasSuffix(r, "/si") {
 		r = r[:len(r)-3]
@@ -179,224 +393,306 @@ func (u *udger) cleanRegex(r string) string {
 	return r
 }
 
-func (u *udger) findDataWithVersion(ua string, data []rexData, withVersion bool) (idx int, value string, err error) {
+// compile compiles the cleaned regex of the rule, case insensitive like the udger parsers.
+func (d *rexData) compile() error {
+	r, err := regexp.Compile("(?i)" + d.Regex)
+	if err != nil {
+		return err
+	}
+	d.RegexCompiled = r
+
+	return nil
+}
+
+func (u *udger) findDataWithVersion(ua string, data []rexData, withVersion bool, stats *lookupStats) (idx int, value string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			idx, value, err = u.findData(ua, data, false)
+			idx, value, err = u.findData(ua, data, false, nil)
 		}
 	}()
 
-	idx, value, err = u.findData(ua, data, withVersion)
+	idx, value, err = u.findData(ua, data, withVersion, stats)
 
 	return idx, value, err
 }
 
-func (u *udger) findData(ua string, data []rexData, withVersion bool) (idx int, value string, err error) {
+func (u *udger) findData(ua string, data []rexData, withVersion bool, stats *lookupStats) (idx int, value string, err error) {
 	for i := 0; i < len(data); i++ {
 		r := data[i].RegexCompiled
-		if !r.MatchString(ua) {
+		if !withVersion {
+			if !r.MatchString(ua) {
+				continue
+			}
+		} else if m := r.FindStringSubmatch(ua); m == nil {
 			continue
+		} else if len(m) > 1 {
+			value = m[1]
+			if !utf8.ValidString(value) {
+				value = strings.ToValidUTF8(value, "\uFFFD")
+			}
+		}
+		if stats != nil {
+			stats.regexes += i + 1
 		}
 
-		// TODO: implement regex for browser version and name support
-		//if withVersion && matcher.Present(1) {
-		//return data[i].ID, matcher.GroupString(1), nil
-		//}
-
-		return data[i].ID, "", nil
+		return data[i].ID, value, nil
+	}
+	if stats != nil {
+		stats.regexes += len(data)
 	}
 
 	return -1, "", nil
 }
 
 func (u *udger) init() error {
-	rows, err := u.db.Query("SELECT client_id, regstring FROM udger_client_regex ORDER by sequence ASC")
-	if err != nil {
-		return err
-	}
-	for rows.Next() {
+	in := make(interner)
+
+	err := u.each(fmt.Sprintf("SELECT client_id, regstring FROM %s ORDER by sequence ASC", u.opts.table("udger_client_regex")), func(rows *sql.Rows) error {
 		var d rexData
-		rows.Scan(&d.ID, &d.Regex)
+		if err := rows.Scan(&d.ID, &d.Regex); err != nil {
+			return err
+		}
 		d.Regex = u.cleanRegex(d.Regex)
-		r, err := regexp.Compile("(?i)" + d.Regex)
-		if err != nil {
+		if err := d.compile(); err != nil {
 			return err
 		}
-		d.RegexCompiled = r
 		u.rexBrowsers = append(u.rexBrowsers, d)
-	}
-	rows.Close()
-
-	rows, err = u.db.Query("SELECT deviceclass_id, regstring FROM udger_deviceclass_regex ORDER by sequence ASC")
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
+
+	err = u.each(fmt.Sprintf("SELECT deviceclass_id, regstring FROM %s ORDER by sequence ASC", u.opts.table("udger_deviceclass_regex")), func(rows *sql.Rows) error {
 		var d rexData
-		rows.Scan(&d.ID, &d.Regex)
+		if err := rows.Scan(&d.ID, &d.Regex); err != nil {
+			return err
+		}
 		d.Regex = u.cleanRegex(d.Regex)
-		r, err := regexp.Compile("(?i)" + d.Regex)
-		if err != nil {
+		if err := d.compile(); err != nil {
 			return err
 		}
-		d.RegexCompiled = r
 		u.rexDevices = append(u.rexDevices, d)
-	}
-	rows.Close()
-
-	rows, err = u.db.Query("SELECT os_id, regstring FROM udger_os_regex ORDER by sequence ASC")
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
+
+	err = u.each(fmt.Sprintf("SELECT os_id, regstring FROM %s ORDER by sequence ASC", u.opts.table("udger_os_regex")), func(rows *sql.Rows) error {
 		var d rexData
-		rows.Scan(&d.ID, &d.Regex)
+		if err := rows.Scan(&d.ID, &d.Regex); err != nil {
+			return err
+		}
 		d.Regex = u.cleanRegex(d.Regex)
-		r, err := regexp.Compile("(?i)" + d.Regex)
-		if err != nil {
+		if err := d.compile(); err != nil {
 			return err
 		}
-		d.RegexCompiled = r
 		u.rexOS = append(u.rexOS, d)
-	}
-	rows.Close()
-
-	rows, err = u.db.Query("SELECT id, class_id, name,engine,vendor,icon FROM udger_client_list")
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var d Browser
-		var id int
-		rows.Scan(&id, &d.typ, &d.Family, &d.Engine, &d.Company, &d.Icon)
-		u.Browsers[id] = d
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, name, family, vendor, icon FROM udger_os_list")
+	err = u.each(fmt.Sprintf("SELECT id, class_id, name,engine,vendor,icon FROM %s", u.opts.table("udger_client_list")), func(rows *sql.Rows) error {
+		var d Browser
+		if err := rows.Scan(&d.ID, &d.typ, &d.Family, &d.Engine, &d.Company, &d.Icon); err != nil {
+			return err
+		}
+		u.browsers[d.ID] = d
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var d OS
-		var id int
-		rows.Scan(&id, &d.Name, &d.Family, &d.Company, &d.Icon)
-		u.OS[id] = d
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, name, icon FROM udger_deviceclass_list")
+	err = u.each(fmt.Sprintf("SELECT id, name, family, family_code, vendor, icon FROM %s", u.opts.table("udger_os_list")), func(rows *sql.Rows) error {
+		var d OS
+		if err := rows.Scan(&d.ID, &d.Name, &d.Family, &d.FamilyCode, &d.Company, &d.Icon); err != nil {
+			return err
+		}
+		u.os[d.ID] = d
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var d Device
-		var id int
-		rows.Scan(&id, &d.Name, &d.Icon)
-		u.Devices[id] = d
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, client_classification FROM udger_client_class")
+	err = u.each(fmt.Sprintf("SELECT id, name, name_code, icon FROM %s", u.opts.table("udger_deviceclass_list")), func(rows *sql.Rows) error {
+		var d Device
+		if err := rows.Scan(&d.ID, &d.Name, &d.Class, &d.Icon); err != nil {
+			return err
+		}
+		u.devices[d.ID] = d
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
+
+	err = u.each(fmt.Sprintf("SELECT id, client_classification, client_classification_code, deviceclass_id FROM %s", u.opts.table("udger_client_class")), func(rows *sql.Rows) error {
 		var d string
+		var code ClientClass
 		var id int
-		rows.Scan(&id, &d)
+		var deviceID sql.NullInt64
+		if err := rows.Scan(&id, &d, &code, &deviceID); err != nil {
+			return err
+		}
 		u.browserTypes[id] = d
-	}
-	rows.Close()
-
-	rows, err = u.db.Query("SELECT client_id, os_id FROM udger_client_os_relation")
+		u.browserClasses[id] = code
+		if deviceID.Int64 != 0 {
+			u.classDevices[id] = int(deviceID.Int64)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
+
+	err = u.each(fmt.Sprintf("SELECT client_id, os_id FROM %s", u.opts.table("udger_client_os_relation")), func(rows *sql.Rows) error {
 		var browser int
 		var os int
-		rows.Scan(&browser, &os)
+		if err := rows.Scan(&browser, &os); err != nil {
+			return err
+		}
 		u.browserOS[browser] = os
-	}
-	rows.Close()
-
-	rows, err = u.db.Query("SELECT ip, class_id, crawler_id, ip_last_seen, ip_hostname, ip_country, ip_city, ip_country_code FROM udger_ip_list")
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var ip IP
-		rows.Scan(&ip.IP, &ip.ClassID, &ip.CrawlerID, &ip.IPLastSeen, &ip.IPHostname, &ip.IPCountry, &ip.IPCity, &ip.IPCountryCode)
-		u.IP[ip.IP] = ip
+
+	if !u.opts.onDemand {
+		err = u.each(fmt.Sprintf("SELECT ip, class_id, crawler_id, ip_last_seen, ip_hostname, ip_country, ip_city, ip_country_code FROM %s", u.opts.table("udger_ip_list")), func(rows *sql.Rows) error {
+			var ip IP
+			if err := rows.Scan(&ip.IP, &ip.ClassID, &ip.CrawlerID, &ip.IPLastSeen, &ip.IPHostname, &ip.IPCountry, &ip.IPCity, &ip.IPCountryCode); err != nil {
+				return err
+			}
+			addr, err := ParseAddr(ip.IP)
+			if err != nil {
+				// reported by MemoryStats, one invalid row does not prevent the load
+				u.skippedIPs++
+				return nil
+			}
+			in.ip(&ip)
+			u.ips[addr] = ip
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = u.each(fmt.Sprintf("SELECT id, ua_string, ver, ver_major, class_id, last_seen, respect_robotstxt, family, family_code, family_homepage, family_icon, vendor, vendor_code, vendor_homepage, name FROM %s", u.opts.table("udger_crawler_list")), func(rows *sql.Rows) error {
+			var c Crawler
+			if err := rows.Scan(&c.ID, &c.UA, &c.Ver, &c.VerMajor, &c.ClassID, &c.LastSeen, &c.RespectRobotstxt, &c.Family, &c.FamilyCode, &c.FamilyHomepage, &c.FamilyIcon, &c.Vendor, &c.VendorCode, &c.VendorHomepage, &c.Name); err != nil {
+				return err
+			}
+			in.crawler(&c)
+			u.crawlers[c.ID] = c
+			u.indexCrawler(c.UA, c.ID)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		err = u.each(fmt.Sprintf("SELECT id, ua_string FROM %s", u.opts.table("udger_crawler_list")), func(rows *sql.Rows) error {
+			var id int
+			var ua string
+			if err := rows.Scan(&id, &ua); err != nil {
+				return err
+			}
+			u.indexCrawler(ua, id)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, ua_string, ver, ver_major, class_id, last_seen, respect_robotstxt, family, family_code, family_homepage, family_icon, vendor, vendor_code, vendor_homepage, name FROM udger_crawler_list")
+	err = u.each(fmt.Sprintf("SELECT id, ip_classification, ip_classification_code FROM %s", u.opts.table("udger_ip_class")), func(rows *sql.Rows) error {
+		var ip IPClass
+		if err := rows.Scan(&ip.ID, &ip.IPClassification, &ip.IPClassificationCode); err != nil {
+			return err
+		}
+		u.ipClasses[ip.ID] = ip
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var c Crawler
-		rows.Scan(&c.ID, &c.UA, &c.Ver, &c.VerMajor, &c.ClassID, &c.LastSeen, &c.RespectRobotstxt, &c.Family, &c.FamilyCode, &c.FamilyHomepage, &c.FamilyIcon, &c.Vendor, &c.VendorCode, &c.VendorHomepage, &c.Name)
-		u.Crawler[c.ID] = c
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, ip_classification, ip_classification_code FROM udger_ip_class")
+	err = u.each(fmt.Sprintf("SELECT id, crawler_classification, crawler_classification_code FROM %s", u.opts.table("udger_crawler_class")), func(rows *sql.Rows) error {
+		var c CrawlerClass
+		if err := rows.Scan(&c.ID, &c.CrawlerClassification, &c.CrawlerClassificationCode); err != nil {
+			return err
+		}
+		u.crawlerClasses[c.ID] = c
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var ip IPClass
-		rows.Scan(&ip.ID, &ip.IPClassification, &ip.IPClassificationCode)
-		u.IPClass[ip.ID] = ip
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, crawler_classification, crawler_classification_code FROM udger_crawler_class")
+	err = u.each(fmt.Sprintf("SELECT id, name, name_code, homepage FROM %s", u.opts.table("udger_datacenter_list")), func(rows *sql.Rows) error {
+		var d DataCenter
+		if err := rows.Scan(&d.ID, &d.Name, &d.NameCode, &d.Homepage); err != nil {
+			return err
+		}
+		u.dataCenters[d.ID] = d
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var c CrawlerClass
-		rows.Scan(&c.ID, &c.CrawlerClassification, &c.CrawlerClassificationCode)
-		u.CrawlerClass[c.ID] = c
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT id, name, name_code, homepage FROM udger_datacenter_list")
+	err = u.each(fmt.Sprintf("SELECT datacenter_id, ip_from, ip_to, iplong_from, iplong_to FROM %s", u.opts.table("udger_datacenter_range")), func(rows *sql.Rows) error {
+		var d DataCenterRange
+		if err := rows.Scan(&d.DatacenterID, &d.IPFrom, &d.IPTo, &d.IPLongFrom, &d.IPLongTo); err != nil {
+			return err
+		}
+		u.dcRanges4 = append(u.dcRanges4, newDCRange4(d))
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	for rows.Next() {
-		var d DataCenter
-		rows.Scan(&d.ID, &d.Name, &d.NameCode, &d.Homepage)
-		u.DataCenter[d.ID] = d
-	}
-	rows.Close()
 
-	rows, err = u.db.Query("SELECT datacenter_id, ip_from, ip_to, iplong_from, iplong_to FROM udger_datacenter_range")
-	if err != nil {
-		return err
+	return u.each(fmt.Sprintf("SELECT datacenter_id, ip_from, ip_to, iplong_from0, iplong_from1, iplong_from2, iplong_from3, iplong_from4, iplong_from5, iplong_from6, iplong_from7, iplong_to0, iplong_to1, iplong_to2, iplong_to3, iplong_to4, iplong_to5, iplong_to6, iplong_to7 FROM %s", u.opts.table("udger_datacenter_range6")), func(rows *sql.Rows) error {
+		var d DataCenterRange6
+		if err := rows.Scan(&d.DatacenterID, &d.IPFrom, &d.IPTo, &d.IPLongFrom0, &d.IPLongFrom1, &d.IPLongFrom2, &d.IPLongFrom3, &d.IPLongFrom4, &d.IPLongFrom5, &d.IPLongFrom6, &d.IPLongFrom7, &d.IPLongTo0, &d.IPLongTo1, &d.IPLongTo2, &d.IPLongTo3, &d.IPLongTo4, &d.IPLongTo5, &d.IPLongTo6, &d.IPLongTo7); err != nil {
+			return err
+		}
+		u.dcRanges6 = append(u.dcRanges6, newDCRange6(d))
+		return nil
+	})
+}
+
+// indexCrawler adds the user agent of a crawler to the index of Lookup, the lowest ID wins.
+func (u *udger) indexCrawler(ua string, id int) {
+	if ua == "" {
+		return
 	}
-	for rows.Next() {
-		var d DataCenterRange
-		rows.Scan(&d.DatacenterID, &d.IPFrom, &d.IPTo, &d.IPLongFrom, &d.IPLongTo)
-		u.DataCenterRange = append(u.DataCenterRange, d)
+	if prev, ok := u.crawlerUAs[ua]; !ok || id < prev {
+		u.crawlerUAs[ua] = id
 	}
-	rows.Close()
+}
 
-	rows, err = u.db.Query("SELECT datacenter_id, ip_from, ip_to, iplong_from0, iplong_from1, iplong_from2, iplong_from3, iplong_from4, iplong_from5, iplong_from6, iplong_from7, iplong_to0, iplong_to1, iplong_to2, iplong_to3, iplong_to4, iplong_to5, iplong_to6, iplong_to7 FROM udger_datacenter_range6")
+// each runs the query and calls fn for every row. The rows are always closed
+// and the first error of the query, of fn or of the iteration is returned.
+func (u *udger) each(query string, fn func(rows *sql.Rows) error) error {
+	rows, err := u.db.Query(query)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var d DataCenterRange6
-		rows.Scan(&d.DatacenterID, &d.IPFrom, &d.IPTo, &d.IPLongFrom0, &d.IPLongFrom1, &d.IPLongFrom2, &d.IPLongFrom3, &d.IPLongFrom4, &d.IPLongFrom5, &d.IPLongFrom6, &d.IPLongFrom7, &d.IPLongTo0, &d.IPLongTo1, &d.IPLongTo2, &d.IPLongTo3, &d.IPLongTo4, &d.IPLongTo5, &d.IPLongTo6, &d.IPLongTo7)
-		u.DataCenterRange6 = append(u.DataCenterRange6, d)
+		if err := fn(rows); err != nil {
+			return err
+		}
 	}
-	rows.Close()
 
-	return nil
+	return rows.Err()
 }
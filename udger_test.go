@@ -1,13 +1,13 @@
 package udger_test
 
 import (
-	"fmt"
+	"database/sql"
 	"net"
+	"strings"
 	"testing"
 
-	"log"
-
 	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -19,22 +19,57 @@ func TestInvalidDbName(t *testing.T) {
 	})
 }
 
+func TestMalformedRows(t *testing.T) {
+	Convey("load a database with a malformed row", t, func() {
+		db, err := sql.Open(udgertest.Driver, createTestDB(t))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		_, err = db.Exec("UPDATE udger_os_list SET id = 'two' WHERE id = 2")
+		So(err, ShouldBeNil)
+
+		u, err := udger.NewFromDB(db)
+		So(err, ShouldNotBeNil)
+		So(u, ShouldBeNil)
+	})
+}
+
 func TestIP(t *testing.T) {
-	u, err := udger.New("./udgerdb_v3.dat")
-	if err != nil {
-		log.Println(fmt.Errorf("ERROR %v", err))
-		t.Fail()
-	}
+	Convey("lookup IPs", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
 
-	// ip := net.ParseIP("1.0.186.186")
-	ip := net.ParseIP("2001:16b0:1003:ffff:ffff:ffff:ffff:ffff")
-	lookup, err := u.LookupIP(ip)
-	fmt.Println(lookup)
+		Convey("a crawler", func() {
+			info, err := u.LookupIP(net.ParseIP("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(info.IP.IPHostname, ShouldEqual, "crawl-66-249-64-1.googlebot.com")
+			So(info.IPClass.IPClassificationCode, ShouldEqual, "crawler")
+			So(info.Crawler.Family, ShouldEqual, "Googlebot")
+			So(info.CrawlerClass.CrawlerClassificationCode, ShouldEqual, "search_engine_bot")
+			So(info.DataCenter.NameCode, ShouldEqual, "google")
+			So(info.DataCenterRange.IPFrom, ShouldEqual, "66.249.64.0")
+		})
+
+		Convey("an IPv6 datacenter", func() {
+			info, err := u.LookupIP(net.ParseIP("2001:4860:1003:ffff:ffff:ffff:ffff:ffff"))
+			So(err, ShouldBeNil)
+			So(info.IP, ShouldResemble, udger.IP{})
+			So(info.DataCenter.NameCode, ShouldEqual, "google")
+			So(info.DataCenterRange6.IPFrom, ShouldEqual, "2001:4860::")
+			So(info.DataCenterRange6.IPLongTo7, ShouldEqual, 0xffff)
+		})
+
+		Convey("an unknown IP", func() {
+			info, err := u.LookupIP(net.ParseIP("10.0.0.1"))
+			So(err, ShouldBeNil)
+			So(info, ShouldResemble, &udger.IPInfo{})
+		})
+	})
 }
 
 func TestValidDbName(t *testing.T) {
 	Convey("load valid path", t, func() {
-		udger, err := udger.New("./udgerdb_v3.dat")
+		udger, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithBrowserVersion())
 		So(err, ShouldBeNil)
 		So(udger, ShouldNotBeNil)
 
@@ -52,6 +87,7 @@ func TestValidDbName(t *testing.T) {
 
 					So(info.Device.Name, ShouldResemble, "Personal computer")
 					So(info.Device.Icon, ShouldResemble, "desktop.png")
+					So(string(info.Device.Class), ShouldEqual, "desktop")
 
 					So(info.Browser.Company, ShouldResemble, "Google Inc.")
 					So(info.Browser.Engine, ShouldResemble, "WebKit/Blink")
@@ -59,6 +95,7 @@ func TestValidDbName(t *testing.T) {
 					So(info.Browser.Icon, ShouldResemble, "chrome.png")
 					So(info.Browser.Name, ShouldResemble, "Chrome 49.0.2575.0")
 					So(info.Browser.Type, ShouldResemble, "Browser")
+					So(string(info.Browser.Class), ShouldEqual, "browser")
 					So(info.Browser.Version, ShouldResemble, "49.0.2575.0")
 				})
 			})
@@ -76,6 +113,7 @@ func TestValidDbName(t *testing.T) {
 
 					So(info.Device.Name, ShouldResemble, "Personal computer")
 					So(info.Device.Icon, ShouldResemble, "desktop.png")
+					So(string(info.Device.Class), ShouldEqual, "desktop")
 
 					So(info.Browser.Company, ShouldResemble, "Microsoft Corporation.")
 					So(info.Browser.Engine, ShouldResemble, "Trident")
@@ -83,6 +121,7 @@ func TestValidDbName(t *testing.T) {
 					So(info.Browser.Icon, ShouldResemble, "msie.png")
 					So(info.Browser.Name, ShouldResemble, "IE 8.0")
 					So(info.Browser.Type, ShouldResemble, "Browser")
+					So(string(info.Browser.Class), ShouldEqual, "browser")
 					So(info.Browser.Version, ShouldResemble, "8.0")
 				})
 			})
@@ -100,6 +139,7 @@ func TestValidDbName(t *testing.T) {
 
 					So(info.Device.Name, ShouldResemble, "Game console")
 					So(info.Device.Icon, ShouldResemble, "console.png")
+					So(string(info.Device.Class), ShouldEqual, "game_console")
 
 					So(info.Browser.Company, ShouldResemble, "Opera Software ASA.")
 					So(info.Browser.Engine, ShouldResemble, "Presto/Blink")
@@ -107,6 +147,7 @@ func TestValidDbName(t *testing.T) {
 					So(info.Browser.Icon, ShouldResemble, "opera.png")
 					So(info.Browser.Name, ShouldResemble, "Opera 9.50")
 					So(info.Browser.Type, ShouldResemble, "Browser")
+					So(string(info.Browser.Class), ShouldEqual, "browser")
 					So(info.Browser.Version, ShouldResemble, "9.50")
 				})
 			})
@@ -124,6 +165,7 @@ func TestValidDbName(t *testing.T) {
 
 					So(info.Device.Name, ShouldResemble, "Smartphone")
 					So(info.Device.Icon, ShouldResemble, "phone.png")
+					So(string(info.Device.Class), ShouldEqual, "smartphone")
 
 					So(info.Browser.Company, ShouldResemble, "Apple Inc.")
 					So(info.Browser.Engine, ShouldResemble, "WebKit")
@@ -131,9 +173,230 @@ func TestValidDbName(t *testing.T) {
 					So(info.Browser.Icon, ShouldResemble, "safari.png")
 					So(info.Browser.Name, ShouldResemble, "Safari mobile ")
 					So(info.Browser.Type, ShouldResemble, "Mobile browser")
+					So(string(info.Browser.Class), ShouldEqual, "mobile_browser")
 					So(info.Browser.Version, ShouldResemble, "")
 				})
 			})
 		})
 	})
 }
+
+func TestBrowserVersion(t *testing.T) {
+	const ie = "Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)"
+
+	Convey("the version is not read by default", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup(ie)
+		So(err, ShouldBeNil)
+		So(info.Browser.Version, ShouldEqual, "")
+		So(info.Browser.Name, ShouldEqual, "IE ")
+	})
+
+	Convey("read the version from the first capture group", t, func() {
+		d := udgertest.Default()
+		d.Clients[1].Regexes = []string{`/chrome\/(\S+)/si`}
+		u, err := udger.New(udgertest.Create(t, d), udger.WithDriver(udgertest.Driver), udger.WithBrowserVersion())
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup(ie)
+		So(err, ShouldBeNil)
+		So(info.Browser.Version, ShouldEqual, "8.0")
+		So(info.Browser.Name, ShouldEqual, "IE 8.0")
+
+		info, err = u.Lookup("Chrome/49.0\xff")
+		So(err, ShouldBeNil)
+		So(info.Browser.Version, ShouldEqual, "49.0\uFFFD")
+
+		info, err = u.Lookup("Mozilla/5.0 (iPhone; CPU iPhone OS 9_2_1 like Mac OS X) AppleWebKit/601.1.46 (KHTML, like Gecko) Mobile/13D15")
+		So(err, ShouldBeNil)
+		So(info.Browser.Family, ShouldEqual, "Safari mobile")
+		So(info.Browser.Version, ShouldEqual, "")
+	})
+}
+
+func TestDeviceFallback(t *testing.T) {
+	const (
+		iphone   = "Mozilla/5.0 (iPhone; CPU iPhone OS 9_2_1 like Mac OS X) AppleWebKit/601.1.46 (KHTML, like Gecko) Mobile/13D15"
+		nintendo = "Opera/9.50 (Nintendo DSi; Opera/507; U; en-US)"
+		ie       = "Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)"
+	)
+
+	// fixture renumbers the client classes and drops the device regexes
+	fixture := func() udgertest.DB {
+		d := udgertest.Default()
+		ids := map[int]int{1: 11, 3: 42, 5: 7}
+		for i := range d.ClientClasses {
+			d.ClientClasses[i].ID = ids[d.ClientClasses[i].ID]
+		}
+		for i := range d.Clients {
+			d.Clients[i].ClassID = ids[d.Clients[i].ClassID]
+		}
+		d.Clients[2].Regexes = []string{`/^curl\/([0-9\.]+)/si`}
+		for i := range d.DeviceClasses {
+			d.DeviceClasses[i].Regexes = nil
+		}
+
+		return d
+	}
+	lookup := func(d udgertest.DB, ua string) *udger.Info {
+		u, err := udger.New(udgertest.Create(t, d), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+		info, err := u.Lookup(ua)
+		So(err, ShouldBeNil)
+
+		return info
+	}
+
+	Convey("detect the device with a regex", t, func() {
+		info := lookup(udgertest.Default(), iphone)
+		So(info.Device.ID, ShouldEqual, 3)
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceRegex)
+		So(info.Recognized.Device, ShouldBeTrue)
+	})
+
+	Convey("use the device class of the client class", t, func() {
+		d := fixture()
+
+		info := lookup(d, iphone)
+		So(info.Device, ShouldResemble, udger.Device{ID: 3, Name: "Smartphone", Class: udger.DeviceClassSmartphone, Icon: "phone.png"})
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceClientClass)
+		So(info.Recognized.Device, ShouldBeFalse)
+
+		info = lookup(d, nintendo)
+		So(info.Device.Class, ShouldEqual, udger.DeviceClassDesktop)
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceClientClass)
+	})
+
+	Convey("use the hints of the operating system", t, func() {
+		d := fixture()
+		for i := range d.ClientClasses {
+			d.ClientClasses[i].DeviceClassID = 0
+		}
+
+		info := lookup(d, iphone)
+		So(info.Device.ID, ShouldEqual, 3)
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceOS)
+
+		info = lookup(d, nintendo)
+		So(info.Device, ShouldResemble, udger.Device{ID: 5, Name: "Game console", Class: udger.DeviceClassGameConsole, Icon: "console.png"})
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceOS)
+
+		Convey("the device class with the lowest ID wins", func() {
+			d.DeviceClasses = append(d.DeviceClasses,
+				udgertest.DeviceClass{ID: 9, Name: "Phablet", Code: udger.DeviceClassSmartphone},
+				udgertest.DeviceClass{ID: 2, Name: "Feature phone", Code: udger.DeviceClassSmartphone},
+			)
+			u, err := udger.New(udgertest.Create(t, d), udger.WithDriver(udgertest.Driver))
+			So(err, ShouldBeNil)
+
+			for i := 0; i < 20; i++ {
+				info, err := u.Lookup(iphone)
+				So(err, ShouldBeNil)
+				So(info.Device.ID, ShouldEqual, 2)
+			}
+		})
+	})
+
+	Convey("guess from the class code of the client", t, func() {
+		d := fixture()
+		for i := range d.ClientClasses {
+			d.ClientClasses[i].DeviceClassID = 0
+		}
+		// the hinted device classes are not in the database
+		d.DeviceClasses = d.DeviceClasses[:1]
+
+		info := lookup(d, iphone)
+		So(info.Browser.Class, ShouldEqual, udger.ClientClassMobileBrowser)
+		So(info.Device, ShouldResemble, udger.Device{Name: "Smartphone", Class: udger.DeviceClassSmartphone, Icon: "phone.png"})
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceGuess)
+
+		info = lookup(d, "curl/7.88.1")
+		So(info.Browser.Class, ShouldEqual, udger.ClientClassLibrary)
+		So(info.Device.Class, ShouldEqual, udger.DeviceClassOther)
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceGuess)
+
+		info = lookup(d, ie)
+		So(info.Device.Class, ShouldEqual, udger.DeviceClassDesktop)
+		So(info.DeviceSource, ShouldEqual, udger.DeviceSourceGuess)
+	})
+}
+
+func TestLookupInto(t *testing.T) {
+	const chrome = "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2575.0 Safari/537.36"
+
+	Convey("lookup into an existing info", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithLookupCache(10))
+		So(err, ShouldBeNil)
+
+		l, ok := u.(udger.IntoLookuper)
+		So(ok, ShouldBeTrue)
+
+		info, err := u.Lookup(chrome)
+		So(err, ShouldBeNil)
+		So(info.CacheHit, ShouldBeFalse)
+
+		dst := udger.Info{Browser: udger.Browser{Family: "stale"}}
+		So(l.LookupInto("curl/7.88.1", &dst), ShouldBeNil)
+		So(dst.Browser.Family, ShouldEqual, "")
+		So(l.LookupInto(chrome, &dst), ShouldBeNil)
+		So(dst.CacheHit, ShouldBeTrue)
+		dst.CacheHit = false
+		So(&dst, ShouldResemble, info)
+
+		Convey("without allocating", func() {
+			allocs := testing.AllocsPerRun(100, func() {
+				_ = l.LookupInto(chrome, &dst)
+			})
+			So(allocs, ShouldEqual, 0)
+		})
+	})
+
+	Convey("lookup the common user agents without allocating", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver))
+		So(err, ShouldBeNil)
+
+		l := u.(udger.IntoLookuper)
+		var dst udger.Info
+		So(l.LookupInto(chrome, &dst), ShouldBeNil)
+		So(dst.Browser.Name, ShouldEqual, "Chrome ")
+		So(dst.CacheHit, ShouldBeFalse)
+		if raceEnabled {
+			return
+		}
+
+		for _, ua := range []string{
+			chrome,
+			"Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)",
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:115.0) Gecko/20100101 Firefox/115.0",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 Mobile/15E148 Safari/604.1",
+			"Googlebot/2.1",
+			"curl/7.88.1",
+		} {
+			allocs := testing.AllocsPerRun(100, func() {
+				_ = l.LookupInto(ua, &dst)
+			})
+			So(allocs, ShouldEqual, 0)
+		}
+	})
+
+	Convey("skip caching the long user agents", t, func() {
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithLookupCache(10))
+		So(err, ShouldBeNil)
+
+		long := chrome + strings.Repeat(" x", 256)
+		info, err := u.Lookup(long)
+		So(err, ShouldBeNil)
+		So(info.Browser.Family, ShouldEqual, "Chrome")
+		info, err = u.Lookup(long)
+		So(err, ShouldBeNil)
+		So(info.CacheHit, ShouldBeFalse)
+
+		info, err = u.Lookup(long[:512])
+		So(err, ShouldBeNil)
+		info, err = u.Lookup(long[:512])
+		So(err, ShouldBeNil)
+		So(info.CacheHit, ShouldBeTrue)
+	})
+}
@@ -0,0 +1,157 @@
+// Package udgerfake implements udger.Client with declarative rules, for the unit tests of the code
+// using udger without a database or mock expectations.
+//
+//	c := udgerfake.New(
+//		udgerfake.WithUA("Googlebot", udger.Info{Browser: udger.Browser{Family: "Googlebot", Type: "Crawler"}}),
+//		udgerfake.WithIP("66.249.64.0/19", udger.IPInfo{IPClass: udger.IPClass{IPClassificationCode: "crawler"}}),
+//	)
+package udgerfake
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/msales/udger"
+)
+
+// DefaultInfo is returned for the user agents matching no rule, like the unknown user agents of udger.
+var DefaultInfo = udger.Info{
+	Device: udger.Device{Name: "Personal computer", Class: udger.DeviceClassDesktop, Icon: "desktop.png"},
+}
+
+// Option configures the fake.
+type Option func(*Client)
+
+// WithUA returns info for the user agents containing substr, case insensitively.
+// The rules are evaluated in order.
+func WithUA(substr string, info udger.Info) Option {
+	return func(c *Client) {
+		c.uas = append(c.uas, uaRule{substr: strings.ToLower(substr), info: info})
+	}
+}
+
+// WithIP returns info for the addresses of the CIDR prefix, it panics if the prefix is invalid.
+// The most specific prefix containing an address wins.
+func WithIP(cidr string, info udger.IPInfo) Option {
+	prefix := netip.MustParsePrefix(cidr)
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+
+	return func(c *Client) {
+		c.ips = append(c.ips, ipRule{prefix: prefix.Masked(), info: info})
+	}
+}
+
+// WithDefaultInfo returns info for the user agents matching no rule instead of DefaultInfo.
+func WithDefaultInfo(info udger.Info) Option {
+	return func(c *Client) {
+		c.defaultInfo = info
+	}
+}
+
+// WithDefaultIPInfo returns info for the addresses matching no rule instead of an empty result.
+func WithDefaultIPInfo(info udger.IPInfo) Option {
+	return func(c *Client) {
+		c.defaultIPInfo = info
+	}
+}
+
+// WithError makes every lookup fail with err.
+func WithError(err error) Option {
+	return func(c *Client) {
+		c.err = err
+	}
+}
+
+type uaRule struct {
+	substr string
+	info   udger.Info
+}
+
+type ipRule struct {
+	prefix netip.Prefix
+	info   udger.IPInfo
+}
+
+// Client is a fake udger.Client, safe for concurrent use. Every lookup returns a new copy of the configured result.
+type Client struct {
+	uas           []uaRule
+	ips           []ipRule
+	defaultInfo   udger.Info
+	defaultIPInfo udger.IPInfo
+	err           error
+}
+
+var _ udger.Client = (*Client)(nil)
+
+// New creates a fake configured with the options.
+func New(opts ...Option) *Client {
+	c := &Client{defaultInfo: DefaultInfo}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Lookup returns the result of the first rule whose substring is in the user agent.
+func (c *Client) Lookup(ua string) (*udger.Info, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	lower := strings.ToLower(ua)
+	for _, r := range c.uas {
+		if strings.Contains(lower, r.substr) {
+			info := r.info
+			return &info, nil
+		}
+	}
+	info := c.defaultInfo
+
+	return &info, nil
+}
+
+// LookupIP returns the result of the most specific prefix containing the IP.
+func (c *Client) LookupIP(ip net.IP) (*udger.IPInfo, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		if c.err != nil {
+			return nil, c.err
+		}
+		return &udger.IPInfo{}, nil
+	}
+
+	return c.LookupAddr(addr)
+}
+
+// LookupAddr returns the result of the most specific prefix containing the address.
+func (c *Client) LookupAddr(addr netip.Addr) (*udger.IPInfo, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if !addr.IsValid() {
+		return &udger.IPInfo{}, nil
+	}
+
+	addr = udger.CanonicalAddr(addr)
+	best := -1
+	for i, r := range c.ips {
+		if r.prefix.Contains(addr) && (best < 0 || r.prefix.Bits() > c.ips[best].prefix.Bits()) {
+			best = i
+		}
+	}
+	info := c.defaultIPInfo
+	if best >= 0 {
+		info = c.ips[best].info
+	}
+
+	return &info, nil
+}
+
+// Close does nothing.
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package udgerfake_test
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgerfake"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClient(t *testing.T) {
+	googlebot := udger.Info{Browser: udger.Browser{Family: "Googlebot", Type: "Crawler"}}
+	chrome := udger.Info{Browser: udger.Browser{Family: "Chrome", Type: "Browser"}}
+
+	Convey("answer from the rules", t, func() {
+		c := udgerfake.New(
+			udgerfake.WithUA("googlebot", googlebot),
+			udgerfake.WithUA("Chrome/", chrome),
+			udgerfake.WithIP("66.249.64.0/19", udger.IPInfo{IPClass: udger.IPClass{IPClassificationCode: "crawler"}}),
+			udgerfake.WithIP("66.249.64.0/24", udger.IPInfo{DataCenter: udger.DataCenter{NameCode: "google"}}),
+			udgerfake.WithIP("2001:4860::/32", udger.IPInfo{DataCenter: udger.DataCenter{NameCode: "google"}}),
+		)
+		var _ udger.Client = c
+
+		Convey("user agents", func() {
+			info, err := c.Lookup("Mozilla/5.0 (compatible; Googlebot/2.1) Chrome/120.0")
+			So(err, ShouldBeNil)
+			So(*info, ShouldResemble, googlebot)
+
+			info, err = c.Lookup("Mozilla/5.0 chrome/120.0")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "Chrome")
+
+			info.Browser.Family = "changed"
+			info, err = c.Lookup("Mozilla/5.0 chrome/120.0")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "Chrome")
+
+			info, err = c.Lookup("unknown")
+			So(err, ShouldBeNil)
+			So(*info, ShouldResemble, udgerfake.DefaultInfo)
+		})
+
+		Convey("addresses", func() {
+			info, err := c.LookupIP(net.ParseIP("66.249.64.1"))
+			So(err, ShouldBeNil)
+			So(info.DataCenter.NameCode, ShouldEqual, "google")
+
+			info, err = c.LookupAddr(netip.MustParseAddr("::ffff:66.249.70.1"))
+			So(err, ShouldBeNil)
+			So(info.IPClass.IPClassificationCode, ShouldEqual, "crawler")
+
+			info, err = c.LookupAddr(netip.MustParseAddr("2001:4860::1"))
+			So(err, ShouldBeNil)
+			So(info.DataCenter.NameCode, ShouldEqual, "google")
+
+			info, err = c.LookupIP(net.ParseIP("10.0.0.1"))
+			So(err, ShouldBeNil)
+			So(info, ShouldResemble, &udger.IPInfo{})
+		})
+	})
+
+	Convey("configure the defaults", t, func() {
+		c := udgerfake.New(
+			udgerfake.WithDefaultInfo(chrome),
+			udgerfake.WithDefaultIPInfo(udger.IPInfo{IPClass: udger.IPClass{IPClassificationCode: "unrecognized"}}),
+		)
+
+		info, err := c.Lookup("anything")
+		So(err, ShouldBeNil)
+		So(*info, ShouldResemble, chrome)
+
+		ipInfo, err := c.LookupIP(net.ParseIP("10.0.0.1"))
+		So(err, ShouldBeNil)
+		So(ipInfo.IPClass.IPClassificationCode, ShouldEqual, "unrecognized")
+	})
+
+	Convey("fail every lookup", t, func() {
+		boom := errors.New("boom")
+		c := udgerfake.New(udgerfake.WithError(boom))
+
+		_, err := c.Lookup("anything")
+		So(err, ShouldEqual, boom)
+		_, err = c.LookupIP(net.ParseIP("10.0.0.1"))
+		So(err, ShouldEqual, boom)
+		So(c.Close(), ShouldBeNil)
+	})
+
+	Convey("reject invalid prefixes", t, func() {
+		So(func() { udgerfake.WithIP("10.0.0.0/33", udger.IPInfo{}) }, ShouldPanic)
+	})
+}
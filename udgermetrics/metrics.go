@@ -0,0 +1,138 @@
+// Package udgermetrics exports Prometheus metrics of the udger lookups and database loads.
+//
+//	m, err := udgermetrics.New(prometheus.DefaultRegisterer)
+//	...
+//	u, err := udger.New(path, udger.WithObserver(m))
+package udgermetrics
+
+import (
+	"github.com/msales/udger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace prefixes the names of the metrics.
+const Namespace = "udger"
+
+// Metrics implements udger.Observer with Prometheus metrics.
+type Metrics struct {
+	lookups        *prometheus.CounterVec
+	errors         *prometheus.CounterVec
+	latency        *prometheus.HistogramVec
+	cache          *prometheus.CounterVec
+	unmatched      prometheus.Counter
+	regexes        prometheus.Histogram
+	dbVersion      *prometheus.GaugeVec
+	loadDuration   prometheus.Gauge
+	loadTimestamp  prometheus.Gauge
+	reloadFailures prometheus.Counter
+}
+
+var _ udger.Observer = (*Metrics)(nil)
+
+// New creates the metrics and registers them on reg.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "lookups_total",
+			Help:      "Number of lookups by type.",
+		}, []string{"type"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "lookup_errors_total",
+			Help:      "Number of failed lookups by type.",
+		}, []string{"type"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "lookup_duration_seconds",
+			Help:      "Duration of the lookups by type.",
+			Buckets:   prometheus.ExponentialBuckets(0.000001, 4, 10),
+		}, []string{"type"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "cache_requests_total",
+			Help:      "Number of cache accesses by result, hit or miss.",
+		}, []string{"result"}),
+		unmatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "unmatched_user_agents_total",
+			Help:      "Number of user agents whose client or operating system is not recognized.",
+		}),
+		regexes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "regexes_evaluated",
+			Help:      "Number of regexes evaluated per user agent lookup.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}),
+		dbVersion: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "database_info",
+			Help:      "Version of the loaded database, always 1.",
+		}, []string{"version"}),
+		loadDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "database_load_duration_seconds",
+			Help:      "Duration of the last successful load of the database.",
+		}),
+		loadTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "database_load_timestamp_seconds",
+			Help:      "Unix time of the last successful load of the database.",
+		}),
+		reloadFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "database_reload_failures_total",
+			Help:      "Number of failed reloads of the database.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.lookups, m.errors, m.latency, m.cache, m.unmatched, m.regexes,
+		m.dbVersion, m.loadDuration, m.loadTimestamp, m.reloadFailures,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ObserveLookup records a lookup.
+func (m *Metrics) ObserveLookup(e udger.LookupEvent) {
+	m.lookups.WithLabelValues(e.Type).Inc()
+	m.latency.WithLabelValues(e.Type).Observe(e.Duration.Seconds())
+	if e.Err != nil {
+		m.errors.WithLabelValues(e.Type).Inc()
+		return
+	}
+
+	if e.CacheHits > 0 {
+		m.cache.WithLabelValues("hit").Add(float64(e.CacheHits))
+	}
+	if e.CacheMisses > 0 {
+		m.cache.WithLabelValues("miss").Add(float64(e.CacheMisses))
+	}
+	if e.Type == udger.LookupTypeUA {
+		m.regexes.Observe(float64(e.Regexes))
+		if e.Unmatched {
+			m.unmatched.Inc()
+		}
+	}
+}
+
+// ObserveLoad records a load of the database, the failed reloads are counted as reload failures.
+// A failed initial load returns an error to the caller and leaves no client to report on.
+func (m *Metrics) ObserveLoad(e udger.LoadEvent) {
+	if e.Err != nil {
+		if e.Reload {
+			m.reloadFailures.Inc()
+		}
+		return
+	}
+
+	m.dbVersion.Reset()
+	m.dbVersion.WithLabelValues(e.Version).Set(1)
+	m.loadDuration.Set(e.Duration.Seconds())
+	m.loadTimestamp.SetToCurrentTime()
+}
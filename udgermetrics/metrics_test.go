@@ -0,0 +1,69 @@
+package udgermetrics_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgermetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetrics(t *testing.T) {
+	Convey("record the lookups and loads", t, func() {
+		reg := prometheus.NewPedanticRegistry()
+		m, err := udgermetrics.New(reg)
+		So(err, ShouldBeNil)
+
+		m.ObserveLoad(udger.LoadEvent{Version: "20240101-01", Duration: 2 * time.Second})
+		m.ObserveLoad(udger.LoadEvent{Err: errors.New("missing")})
+		m.ObserveLoad(udger.LoadEvent{Reload: true, Err: errors.New("locked")})
+		m.ObserveLookup(udger.LookupEvent{Type: udger.LookupTypeUA, Duration: time.Millisecond, Regexes: 12, Unmatched: true})
+		m.ObserveLookup(udger.LookupEvent{Type: udger.LookupTypeUA, Duration: time.Millisecond, Regexes: 3})
+		m.ObserveLookup(udger.LookupEvent{Type: udger.LookupTypeIP, Duration: time.Microsecond, CacheHits: 1, CacheMisses: 1})
+		m.ObserveLookup(udger.LookupEvent{Type: udger.LookupTypeIP, Err: errors.New("closed")})
+
+		err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP udger_lookups_total Number of lookups by type.
+# TYPE udger_lookups_total counter
+udger_lookups_total{type="ip"} 2
+udger_lookups_total{type="ua"} 2
+# HELP udger_lookup_errors_total Number of failed lookups by type.
+# TYPE udger_lookup_errors_total counter
+udger_lookup_errors_total{type="ip"} 1
+# HELP udger_cache_requests_total Number of cache accesses by result, hit or miss.
+# TYPE udger_cache_requests_total counter
+udger_cache_requests_total{result="hit"} 1
+udger_cache_requests_total{result="miss"} 1
+# HELP udger_unmatched_user_agents_total Number of user agents whose client or operating system is not recognized.
+# TYPE udger_unmatched_user_agents_total counter
+udger_unmatched_user_agents_total 1
+# HELP udger_database_info Version of the loaded database, always 1.
+# TYPE udger_database_info gauge
+udger_database_info{version="20240101-01"} 1
+# HELP udger_database_load_duration_seconds Duration of the last successful load of the database.
+# TYPE udger_database_load_duration_seconds gauge
+udger_database_load_duration_seconds 2
+# HELP udger_database_reload_failures_total Number of failed reloads of the database.
+# TYPE udger_database_reload_failures_total counter
+udger_database_reload_failures_total 1
+`),
+			"udger_lookups_total", "udger_lookup_errors_total", "udger_cache_requests_total",
+			"udger_unmatched_user_agents_total", "udger_database_info",
+			"udger_database_load_duration_seconds", "udger_database_reload_failures_total",
+		)
+		So(err, ShouldBeNil)
+
+		So(testutil.CollectAndCount(reg, "udger_regexes_evaluated"), ShouldEqual, 1)
+		So(testutil.CollectAndCount(reg, "udger_lookup_duration_seconds"), ShouldEqual, 2)
+
+		Convey("refuse to register twice", func() {
+			_, err := udgermetrics.New(reg)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -5,6 +5,8 @@ package udgermocks
 import (
 	net "net"
 
+	netip "net/netip"
+
 	mock "github.com/stretchr/testify/mock"
 
 	udger "github.com/msales/udger"
@@ -15,6 +17,20 @@ type Client struct {
 	mock.Mock
 }
 
+// Close provides a mock function with given fields:
+func (_m *Client) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Lookup provides a mock function with given fields: ua
 func (_m *Client) Lookup(ua string) (*udger.Info, error) {
 	ret := _m.Called(ua)
@@ -38,6 +54,29 @@ func (_m *Client) Lookup(ua string) (*udger.Info, error) {
 	return r0, r1
 }
 
+// LookupAddr provides a mock function with given fields: addr
+func (_m *Client) LookupAddr(addr netip.Addr) (*udger.IPInfo, error) {
+	ret := _m.Called(addr)
+
+	var r0 *udger.IPInfo
+	if rf, ok := ret.Get(0).(func(netip.Addr) *udger.IPInfo); ok {
+		r0 = rf(addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*udger.IPInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(netip.Addr) error); ok {
+		r1 = rf(addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LookupIP provides a mock function with given fields: ip
 func (_m *Client) LookupIP(ip net.IP) (*udger.IPInfo, error) {
 	ret := _m.Called(ip)
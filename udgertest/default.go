@@ -0,0 +1,61 @@
+package udgertest
+
+import "github.com/msales/udger"
+
+// Default returns a database with a few common browsers, operating systems, devices and crawlers:
+//
+//	Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)                    IE 8.0 on Windows 7
+//	Mozilla/5.0 (Macintosh; Intel Mac OS X 10_11_3) ... Chrome/49.0.2575.0 Safari/537.36  Chrome on OS X
+//	Opera/9.50 (Nintendo DSi; Opera/507; U; en-US)                                      Opera on a game console
+//	Mozilla/5.0 (iPhone; CPU iPhone OS 9_2_1 like Mac OS X) ... Mobile/13D15            Safari mobile on iOS
+//	66.249.64.1                                                                         Googlebot
+//	66.249.64.0-66.249.95.255, 2001:4860::/32                                          Google datacenter
+func Default() DB {
+	return DB{
+		Version: "20240101-01",
+		ClientClasses: []ClientClass{
+			{ID: 1, Name: "Browser", Code: udger.ClientClassBrowser, DeviceClassID: 1},
+			{ID: 3, Name: "Mobile browser", Code: udger.ClientClassMobileBrowser, DeviceClassID: 3},
+			{ID: 5, Name: "Library", Code: udger.ClientClassLibrary},
+		},
+		Clients: []Client{
+			{ID: 1, ClassID: 1, Name: "IE", Engine: "Trident", Vendor: "Microsoft Corporation.", Icon: "msie.png", Regexes: []string{`/msie ([0-9a-z\._]+)/si`}},
+			{ID: 2, ClassID: 1, Name: "Chrome", Engine: "WebKit/Blink", Vendor: "Google Inc.", Icon: "chrome.png", Regexes: []string{`/chrome\/([0-9\.]+)/si`}},
+			{ID: 3, ClassID: 5, Name: "curl", Vendor: "Daniel Stenberg", Icon: "curl.png"},
+			{ID: 4, ClassID: 1, Name: "Opera", Engine: "Presto/Blink", Vendor: "Opera Software ASA.", Icon: "opera.png", Regexes: []string{`/^opera\/([0-9\.]+)/si`}},
+			{ID: 5, ClassID: 3, Name: "Safari mobile", Engine: "WebKit", Vendor: "Apple Inc.", Icon: "safari.png", Regexes: []string{`/(?:iphone|ipad|ipod).*applewebkit.*mobile\//si`}},
+		},
+		OS: []OS{
+			{ID: 2, Name: "Windows 7", Family: "Windows", FamilyCode: "windows", Vendor: "Microsoft Corporation.", Icon: "windows-7.png", Regexes: []string{`/windows nt 6\.1/si`}},
+			{ID: 3, Name: "Linux", Family: "Linux", FamilyCode: "linux", Vendor: "Linux Foundation", Icon: "linux.png"},
+			{ID: 4, Name: "iOS", Family: "iOS", FamilyCode: "ios", Vendor: "Apple Inc.", Icon: "iphone.png", Regexes: []string{`/iphone os/si`}},
+			{ID: 5, Name: "OS X 10.11 El Capitan", Family: "OS X", FamilyCode: "osx", Vendor: "Apple Computer, Inc.", Icon: "macosx.png", Regexes: []string{`/mac os x 10[_\.]11/si`}},
+			{ID: 6, Name: "Nintendo DS", Family: "Nintendo", FamilyCode: "nintendo", Vendor: "Nintendo of America Inc.", Icon: "nintendoDS.png", Regexes: []string{`/nintendo dsi?/si`}},
+		},
+		DeviceClasses: []DeviceClass{
+			{ID: 1, Name: "Personal computer", Code: udger.DeviceClassDesktop, Icon: "desktop.png"},
+			{ID: 3, Name: "Smartphone", Code: udger.DeviceClassSmartphone, Icon: "phone.png", Regexes: []string{`/iphone/si`}},
+			{ID: 5, Name: "Game console", Code: udger.DeviceClassGameConsole, Icon: "console.png", Regexes: []string{`/nintendo/si`}},
+		},
+		IPClasses: []udger.IPClass{
+			{ID: 1, IPClassification: "Crawler", IPClassificationCode: "crawler"},
+		},
+		IPs: []udger.IP{
+			{IP: "66.249.64.1", ClassID: 1, CrawlerID: 3, IPLastSeen: "2016-01-01", IPHostname: "crawl-66-249-64-1.googlebot.com", IPCountry: "United States", IPCity: "Mountain View", IPCountryCode: "US"},
+		},
+		CrawlerClasses: []udger.CrawlerClass{
+			{ID: 4, CrawlerClassification: "Search engine bot", CrawlerClassificationCode: "search_engine_bot"},
+		},
+		Crawlers: []udger.Crawler{
+			{ID: 3, UA: "Googlebot/2.1", Ver: "2.1", VerMajor: "2", ClassID: 4, LastSeen: "2016-01-01", RespectRobotstxt: "yes", Family: "Googlebot", FamilyCode: "googlebot", Vendor: "Google Inc.", VendorCode: "google_inc", Name: "Googlebot/2.1"},
+			{ID: 6, UA: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", Ver: "2.0", VerMajor: "2", ClassID: 4, LastSeen: "2016-01-01", RespectRobotstxt: "yes", Family: "Bingbot", FamilyCode: "bingbot", Vendor: "Microsoft Corporation", VendorCode: "microsoft_corporation", Name: "bingbot/2.0"},
+		},
+		DataCenters: []udger.DataCenter{
+			{ID: 5, Name: "Google sites", NameCode: "google", Homepage: "https://sites.google.com/"},
+		},
+		DataCenterRanges: []DataCenterRange{
+			{DataCenterID: 5, From: "66.249.64.0", To: "66.249.95.255"},
+			{DataCenterID: 5, From: "2001:4860::", To: "2001:4860:ffff:ffff:ffff:ffff:ffff:ffff"},
+		},
+	}
+}
@@ -0,0 +1,275 @@
+package udgertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/msales/udger"
+)
+
+// Case is an entry of a corpus, in the format of the test files of the official udger parsers:
+//
+//	[{"test": {"teststring": "Mozilla/5.0 ..."}, "ret": {"ua_family": "Chrome", ...}}]
+//
+// The test strings parsing as an IP address are looked up with LookupAddr, the others with Lookup.
+type Case struct {
+	Test struct {
+		TestString string `json:"teststring"`
+	} `json:"test"`
+	Ret map[string]interface{} `json:"ret"`
+}
+
+// LoadCorpus reads a corpus file.
+func LoadCorpus(path string) ([]Case, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	if err := json.Unmarshal(b, &cases); err != nil {
+		return nil, fmt.Errorf("udgertest: invalid corpus %s: %w", path, err)
+	}
+
+	return cases, nil
+}
+
+// WriteCorpus writes a corpus file.
+func WriteCorpus(path string, cases []Case) error {
+	b, err := json.MarshalIndent(cases, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Fields returns the result of a user agent lookup with the field names of the official parsers.
+func Fields(info *udger.Info) map[string]string {
+	major := info.Browser.Version
+	if i := strings.IndexByte(major, '.'); i >= 0 {
+		major = major[:i]
+	}
+
+	return map[string]string{
+		"ua":                info.Browser.Name,
+		"ua_class":          info.Browser.Type,
+		"ua_class_code":     string(info.Browser.Class),
+		"ua_version":        info.Browser.Version,
+		"ua_version_major":  major,
+		"ua_family":         info.Browser.Family,
+		"ua_family_vendor":  info.Browser.Company,
+		"ua_family_icon":    info.Browser.Icon,
+		"ua_engine":         info.Browser.Engine,
+		"os":                info.OS.Name,
+		"os_family":         info.OS.Family,
+		"os_family_vendor":  info.OS.Company,
+		"os_icon":           info.OS.Icon,
+		"device_class":      info.Device.Name,
+		"device_class_code": string(info.Device.Class),
+		"device_class_icon": info.Device.Icon,
+	}
+}
+
+// IPFields returns the result of an IP lookup with the field names of the official parsers.
+func IPFields(info *udger.IPInfo) map[string]string {
+	return map[string]string{
+		"ip_classification":              info.IPClass.IPClassification,
+		"ip_classification_code":         info.IPClass.IPClassificationCode,
+		"ip_last_seen":                   info.IP.IPLastSeen,
+		"ip_hostname":                    info.IP.IPHostname,
+		"ip_country":                     info.IP.IPCountry,
+		"ip_country_code":                info.IP.IPCountryCode,
+		"ip_city":                        info.IP.IPCity,
+		"crawler_name":                   info.Crawler.Name,
+		"crawler_ver":                    info.Crawler.Ver,
+		"crawler_ver_major":              info.Crawler.VerMajor,
+		"crawler_family":                 info.Crawler.Family,
+		"crawler_family_code":            info.Crawler.FamilyCode,
+		"crawler_family_homepage":        info.Crawler.FamilyHomepage,
+		"crawler_family_vendor":          info.Crawler.Vendor,
+		"crawler_family_vendor_code":     info.Crawler.VendorCode,
+		"crawler_family_vendor_homepage": info.Crawler.VendorHomepage,
+		"crawler_family_icon":            info.Crawler.FamilyIcon,
+		"crawler_last_seen":              info.Crawler.LastSeen,
+		"crawler_class":                  info.CrawlerClass.CrawlerClassification,
+		"crawler_class_code":             info.CrawlerClass.CrawlerClassificationCode,
+		"crawler_respect_robotstxt":      info.Crawler.RespectRobotstxt,
+		"datacenter_name":                info.DataCenter.Name,
+		"datacenter_name_code":           info.DataCenter.NameCode,
+		"datacenter_homepage":            info.DataCenter.Homepage,
+	}
+}
+
+// lookup returns the fields of the result of the test string.
+func lookup(c udger.Client, s string) (map[string]string, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		info, err := c.LookupAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		return IPFields(info), nil
+	}
+
+	info, err := c.Lookup(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return Fields(info), nil
+}
+
+// Diff is a field whose value differs from the expected one.
+type Diff struct {
+	TestString string
+	Field      string
+	Want       string
+	Got        string
+}
+
+// FieldStats counts the comparisons of a field.
+type FieldStats struct {
+	Field   string
+	Total   int
+	Matched int
+}
+
+// Accuracy returns the percentage of matching values.
+func (s FieldStats) Accuracy() float64 {
+	return percent(s.Matched, s.Total)
+}
+
+// Report is the result of the comparison of a corpus. Unsupported lists the expected
+// fields the client does not return, they are not part of the accuracy.
+type Report struct {
+	Cases       int
+	Passed      int
+	Fields      []FieldStats
+	Unsupported []string
+	Diffs       []Diff
+}
+
+// Accuracy returns the percentage of the cases whose fields all match.
+func (r Report) Accuracy() float64 {
+	return percent(r.Passed, r.Cases)
+}
+
+// String formats the accuracy of each field, the unsupported fields, then the differences.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d/%d cases passed (%.2f%%)\n", r.Passed, r.Cases, r.Accuracy())
+	for _, f := range r.Fields {
+		fmt.Fprintf(&b, "  %-32s %d/%d (%.2f%%)\n", f.Field, f.Matched, f.Total, f.Accuracy())
+	}
+	for _, f := range r.Unsupported {
+		fmt.Fprintf(&b, "  %-32s unsupported\n", f)
+	}
+	for _, d := range r.Diffs {
+		fmt.Fprintf(&b, "%q: %s: want %q, got %q\n", d.TestString, d.Field, d.Want, d.Got)
+	}
+
+	return b.String()
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return 100 * float64(n) / float64(total)
+}
+
+// Compare looks up the cases and compares the fields of the results with the expected ones.
+// The expected fields the client does not return are reported as unsupported.
+func Compare(c udger.Client, cases []Case) (Report, error) {
+	r := Report{Cases: len(cases)}
+	stats := make(map[string]*FieldStats)
+	unsupported := make(map[string]bool)
+
+	for _, tc := range cases {
+		got, err := lookup(c, tc.Test.TestString)
+		if err != nil {
+			return Report{}, fmt.Errorf("udgertest: %q: %w", tc.Test.TestString, err)
+		}
+
+		passed := true
+		for _, field := range sortedKeys(tc.Ret) {
+			g, ok := got[field]
+			if !ok {
+				unsupported[field] = true
+				continue
+			}
+			s, ok := stats[field]
+			if !ok {
+				s = &FieldStats{Field: field}
+				stats[field] = s
+			}
+			s.Total++
+
+			if want := value(tc.Ret[field]); want != g {
+				passed = false
+				r.Diffs = append(r.Diffs, Diff{TestString: tc.Test.TestString, Field: field, Want: want, Got: g})
+				continue
+			}
+			s.Matched++
+		}
+		if passed {
+			r.Passed++
+		}
+	}
+
+	for _, s := range stats {
+		r.Fields = append(r.Fields, *s)
+	}
+	sort.Slice(r.Fields, func(i, j int) bool { return r.Fields[i].Field < r.Fields[j].Field })
+	if len(unsupported) > 0 {
+		r.Unsupported = sortedKeys(unsupported)
+	}
+
+	return r, nil
+}
+
+// Generate returns the cases with the results of the client as expected fields, to regenerate a golden file.
+func Generate(c udger.Client, cases []Case) ([]Case, error) {
+	out := make([]Case, len(cases))
+	for i, tc := range cases {
+		got, err := lookup(c, tc.Test.TestString)
+		if err != nil {
+			return nil, fmt.Errorf("udgertest: %q: %w", tc.Test.TestString, err)
+		}
+
+		out[i] = tc
+		out[i].Ret = make(map[string]interface{}, len(got))
+		for k, v := range got {
+			out[i].Ret[k] = v
+		}
+	}
+
+	return out, nil
+}
+
+// value formats an expected value, the official files contain strings, numbers and nulls.
+func value(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,63 @@
+package udgertest_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgerfake"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testCorpus = `[
+  {"test": {"teststring": "Chrome/49.0"}, "ret": {"ua_family": "Chrome", "ua_version_major": 49, "ua_family_code": "chrome"}},
+  {"test": {"teststring": "unknown"}, "ret": {"ua_family": "Firefox", "os": null}},
+  {"test": {"teststring": "66.249.64.1"}, "ret": {"ip_classification_code": "crawler", "crawler_family_code": "googlebot"}}
+]`
+
+func TestCompare(t *testing.T) {
+	Convey("compare a corpus", t, func() {
+		var cases []udgertest.Case
+		So(json.Unmarshal([]byte(testCorpus), &cases), ShouldBeNil)
+
+		c := udgerfake.New(
+			udgerfake.WithUA("chrome/", udger.Info{Browser: udger.Browser{Family: "Chrome", Version: "49.0"}}),
+			udgerfake.WithIP("66.249.64.0/19", udger.IPInfo{IPClass: udger.IPClass{IPClassificationCode: "crawler"}}),
+		)
+
+		report, err := udgertest.Compare(c, cases)
+		So(err, ShouldBeNil)
+		So(report.Cases, ShouldEqual, 3)
+		So(report.Passed, ShouldEqual, 1)
+		So(report.Diffs, ShouldResemble, []udgertest.Diff{
+			{TestString: "unknown", Field: "ua_family", Want: "Firefox", Got: ""},
+			{TestString: "66.249.64.1", Field: "crawler_family_code", Want: "googlebot", Got: ""},
+		})
+		So(report.Fields, ShouldResemble, []udgertest.FieldStats{
+			{Field: "crawler_family_code", Total: 1, Matched: 0},
+			{Field: "ip_classification_code", Total: 1, Matched: 1},
+			{Field: "os", Total: 1, Matched: 1},
+			{Field: "ua_family", Total: 2, Matched: 1},
+			{Field: "ua_version_major", Total: 1, Matched: 1},
+		})
+		So(report.Unsupported, ShouldResemble, []string{"ua_family_code"})
+		So(report.String(), ShouldContainSubstring, "1/3 cases passed")
+		So(report.String(), ShouldContainSubstring, "ua_family_code")
+
+		Convey("regenerate the expected fields", func() {
+			cases, err := udgertest.Generate(c, cases)
+			So(err, ShouldBeNil)
+
+			path := filepath.Join(t.TempDir(), "corpus.json")
+			So(udgertest.WriteCorpus(path, cases), ShouldBeNil)
+			cases, err = udgertest.LoadCorpus(path)
+			So(err, ShouldBeNil)
+
+			report, err := udgertest.Compare(c, cases)
+			So(err, ShouldBeNil)
+			So(report.Accuracy(), ShouldEqual, 100)
+		})
+	})
+}
@@ -0,0 +1,246 @@
+// Package udgertest builds small databases with the udger v3 schema for tests, with the pure Go
+// SQLite driver registered as Driver.
+//
+//	u, err := udger.New(udgertest.Create(t, udgertest.Default()), udger.WithDriver(udgertest.Driver))
+package udgertest
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msales/udger"
+	_ "modernc.org/sqlite" // registers the Driver
+)
+
+// Driver is the name of the database/sql driver to open the databases with.
+const Driver = "sqlite"
+
+// DB is the content of a database. The regexes use the udger syntax, e.g. /chrome\/([0-9.]+)/si,
+// and are evaluated in the order of the entries, then of the regexes of each entry.
+type DB struct {
+	Version          string
+	ClientClasses    []ClientClass
+	Clients          []Client
+	OS               []OS
+	DeviceClasses    []DeviceClass
+	IPClasses        []udger.IPClass
+	IPs              []udger.IP
+	CrawlerClasses   []udger.CrawlerClass
+	Crawlers         []udger.Crawler
+	DataCenters      []udger.DataCenter
+	DataCenterRanges []DataCenterRange
+}
+
+// ClientClass is a row of udger_client_class.
+type ClientClass struct {
+	ID   int
+	Name string
+	Code udger.ClientClass
+	// DeviceClassID is the device class of the clients whose device is not detected, if any
+	DeviceClassID int
+}
+
+// Client is a row of udger_client_list, with its regexes and the ID of its OS, if any.
+type Client struct {
+	ID      int
+	ClassID int
+	Name    string
+	Engine  string
+	Vendor  string
+	Icon    string
+	OSID    int
+	Regexes []string
+}
+
+// OS is a row of udger_os_list with its regexes.
+type OS struct {
+	ID         int
+	Name       string
+	Family     string
+	FamilyCode string
+	Vendor     string
+	Icon       string
+	Regexes    []string
+}
+
+// DeviceClass is a row of udger_deviceclass_list with its regexes.
+type DeviceClass struct {
+	ID      int
+	Name    string
+	Code    udger.DeviceClass
+	Icon    string
+	Regexes []string
+}
+
+// DataCenterRange is an inclusive range of IPv4 or IPv6 addresses of a datacenter.
+type DataCenterRange struct {
+	DataCenterID int
+	From         string
+	To           string
+}
+
+// Schema contains the statements creating the tables of the udger v3 schema.
+var Schema = []string{
+	"CREATE TABLE udger_db_info (key TEXT, version TEXT, information TEXT, lastupdate INTEGER)",
+	"CREATE TABLE udger_client_regex (client_id INTEGER, regstring TEXT, sequence INTEGER)",
+	"CREATE TABLE udger_deviceclass_regex (deviceclass_id INTEGER, regstring TEXT, sequence INTEGER)",
+	"CREATE TABLE udger_os_regex (os_id INTEGER, regstring TEXT, sequence INTEGER)",
+	"CREATE TABLE udger_client_list (id INTEGER, class_id INTEGER, name TEXT, engine TEXT, vendor TEXT, icon TEXT)",
+	"CREATE TABLE udger_os_list (id INTEGER, name TEXT, family TEXT, family_code TEXT, vendor TEXT, icon TEXT)",
+	"CREATE TABLE udger_deviceclass_list (id INTEGER, name TEXT, name_code TEXT, icon TEXT)",
+	"CREATE TABLE udger_client_class (id INTEGER, client_classification TEXT, client_classification_code TEXT, deviceclass_id INTEGER)",
+	"CREATE TABLE udger_client_os_relation (client_id INTEGER, os_id INTEGER)",
+	"CREATE TABLE udger_ip_list (ip TEXT, class_id INTEGER, crawler_id INTEGER, ip_last_seen TEXT, ip_hostname TEXT, ip_country TEXT, ip_city TEXT, ip_country_code TEXT)",
+	"CREATE TABLE udger_crawler_list (id INTEGER, ua_string TEXT, ver TEXT, ver_major TEXT, class_id INTEGER, last_seen TEXT, respect_robotstxt TEXT, family TEXT, family_code TEXT, family_homepage TEXT, family_icon TEXT, vendor TEXT, vendor_code TEXT, vendor_homepage TEXT, name TEXT)",
+	"CREATE TABLE udger_ip_class (id INTEGER, ip_classification TEXT, ip_classification_code TEXT)",
+	"CREATE TABLE udger_crawler_class (id INTEGER, crawler_classification TEXT, crawler_classification_code TEXT)",
+	"CREATE TABLE udger_datacenter_list (id INTEGER, name TEXT, name_code TEXT, homepage TEXT)",
+	"CREATE TABLE udger_datacenter_range (datacenter_id INTEGER, ip_from TEXT, ip_to TEXT, iplong_from INTEGER, iplong_to INTEGER)",
+	"CREATE TABLE udger_datacenter_range6 (datacenter_id INTEGER, ip_from TEXT, ip_to TEXT, iplong_from0 INTEGER, iplong_from1 INTEGER, iplong_from2 INTEGER, iplong_from3 INTEGER, iplong_from4 INTEGER, iplong_from5 INTEGER, iplong_from6 INTEGER, iplong_from7 INTEGER, iplong_to0 INTEGER, iplong_to1 INTEGER, iplong_to2 INTEGER, iplong_to3 INTEGER, iplong_to4 INTEGER, iplong_to5 INTEGER, iplong_to6 INTEGER, iplong_to7 INTEGER)",
+}
+
+// Create writes the database in a temporary directory of the test and returns its path.
+func Create(tb testing.TB, d DB) string {
+	tb.Helper()
+
+	path := filepath.Join(tb.TempDir(), "udgerdb_v3.dat")
+	if err := d.Write(path); err != nil {
+		tb.Fatal(err)
+	}
+
+	return path
+}
+
+// Write creates the database at path.
+func (d DB) Write(path string) error {
+	db, err := sql.Open(Driver, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return d.Insert(db)
+}
+
+// Insert creates the tables and inserts the rows in an open database.
+func (d DB) Insert(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	w := writer{tx: tx}
+	for _, q := range Schema {
+		w.exec(q)
+	}
+
+	if d.Version != "" {
+		w.exec("INSERT INTO udger_db_info VALUES (?, ?, ?, ?)", "udger_db", d.Version, "udgertest", 0)
+	}
+	for _, c := range d.ClientClasses {
+		w.exec("INSERT INTO udger_client_class VALUES (?, ?, ?, ?)", c.ID, c.Name, string(c.Code), c.DeviceClassID)
+	}
+	for _, c := range d.Clients {
+		w.exec("INSERT INTO udger_client_list VALUES (?, ?, ?, ?, ?, ?)", c.ID, c.ClassID, c.Name, c.Engine, c.Vendor, c.Icon)
+		if c.OSID != 0 {
+			w.exec("INSERT INTO udger_client_os_relation VALUES (?, ?)", c.ID, c.OSID)
+		}
+		w.regexes("udger_client_regex", c.ID, c.Regexes)
+	}
+	for _, o := range d.OS {
+		w.exec("INSERT INTO udger_os_list VALUES (?, ?, ?, ?, ?, ?)", o.ID, o.Name, o.Family, o.FamilyCode, o.Vendor, o.Icon)
+		w.regexes("udger_os_regex", o.ID, o.Regexes)
+	}
+	for _, c := range d.DeviceClasses {
+		w.exec("INSERT INTO udger_deviceclass_list VALUES (?, ?, ?, ?)", c.ID, c.Name, string(c.Code), c.Icon)
+		w.regexes("udger_deviceclass_regex", c.ID, c.Regexes)
+	}
+	for _, c := range d.IPClasses {
+		w.exec("INSERT INTO udger_ip_class VALUES (?, ?, ?)", c.ID, c.IPClassification, c.IPClassificationCode)
+	}
+	for _, ip := range d.IPs {
+		w.exec("INSERT INTO udger_ip_list VALUES (?, ?, ?, ?, ?, ?, ?, ?)", ip.IP, ip.ClassID, ip.CrawlerID, ip.IPLastSeen, ip.IPHostname, ip.IPCountry, ip.IPCity, ip.IPCountryCode)
+	}
+	for _, c := range d.CrawlerClasses {
+		w.exec("INSERT INTO udger_crawler_class VALUES (?, ?, ?)", c.ID, c.CrawlerClassification, c.CrawlerClassificationCode)
+	}
+	for _, c := range d.Crawlers {
+		w.exec("INSERT INTO udger_crawler_list VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", c.ID, c.UA, c.Ver, c.VerMajor, c.ClassID, c.LastSeen, c.RespectRobotstxt, c.Family, c.FamilyCode, c.FamilyHomepage, c.FamilyIcon, c.Vendor, c.VendorCode, c.VendorHomepage, c.Name)
+	}
+	for _, dc := range d.DataCenters {
+		w.exec("INSERT INTO udger_datacenter_list VALUES (?, ?, ?, ?)", dc.ID, dc.Name, dc.NameCode, dc.Homepage)
+	}
+	for _, r := range d.DataCenterRanges {
+		w.dataCenterRange(r)
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	return tx.Commit()
+}
+
+// writer keeps the first error of a sequence of statements.
+type writer struct {
+	tx       *sql.Tx
+	sequence int
+	err      error
+}
+
+func (w *writer) exec(q string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	if _, err := w.tx.Exec(q, args...); err != nil {
+		w.err = fmt.Errorf("udgertest: %s: %w", strings.Fields(q)[2], err)
+	}
+}
+
+func (w *writer) regexes(table string, id int, regexes []string) {
+	for _, r := range regexes {
+		w.sequence++
+		w.exec(fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?)", table), id, r, w.sequence)
+	}
+}
+
+func (w *writer) dataCenterRange(r DataCenterRange) {
+	if w.err != nil {
+		return
+	}
+	from, err := netip.ParseAddr(r.From)
+	if err != nil {
+		w.err = fmt.Errorf("udgertest: datacenter range: %w", err)
+		return
+	}
+	to, err := netip.ParseAddr(r.To)
+	if err != nil {
+		w.err = fmt.Errorf("udgertest: datacenter range: %w", err)
+		return
+	}
+	if from.Is4() != to.Is4() {
+		w.err = fmt.Errorf("udgertest: datacenter range %s-%s mixes address families", r.From, r.To)
+		return
+	}
+
+	if from.Is4() {
+		f, t := from.As4(), to.As4()
+		w.exec("INSERT INTO udger_datacenter_range VALUES (?, ?, ?, ?, ?)", r.DataCenterID, from.String(), to.String(),
+			binary.BigEndian.Uint32(f[:]), binary.BigEndian.Uint32(t[:]))
+		return
+	}
+
+	args := []interface{}{r.DataCenterID, from.String(), to.String()}
+	for _, addr := range []netip.Addr{from, to} {
+		b := addr.As16()
+		for i := 0; i < 8; i++ {
+			args = append(args, binary.BigEndian.Uint16(b[2*i:]))
+		}
+	}
+	w.exec("INSERT INTO udger_datacenter_range6 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", args...)
+}
@@ -0,0 +1,51 @@
+package udgertest_test
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreate(t *testing.T) {
+	Convey("build a custom database", t, func() {
+		d := udgertest.DB{
+			ClientClasses: []udgertest.ClientClass{{ID: 1, Name: "Browser"}},
+			Clients: []udgertest.Client{
+				{ID: 10, ClassID: 1, Name: "Firefox", OSID: 20, Regexes: []string{`/firefox\/([0-9.]+)/si`}},
+			},
+			OS:          []udgertest.OS{{ID: 20, Name: "Linux", Family: "Linux"}},
+			DataCenters: []udger.DataCenter{{ID: 30, NameCode: "hetzner"}},
+			DataCenterRanges: []udgertest.DataCenterRange{
+				{DataCenterID: 30, From: "5.9.0.0", To: "5.9.255.255"},
+				{DataCenterID: 30, From: "2a01:4f8::", To: "2a01:4f8:ffff:ffff:ffff:ffff:ffff:ffff"},
+			},
+		}
+		u, err := udger.New(udgertest.Create(t, d), udger.WithDriver(udgertest.Driver), udger.WithBrowserVersion())
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup("Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0")
+		So(err, ShouldBeNil)
+		So(info.Browser.Name, ShouldEqual, "Firefox 115.0")
+		So(info.Browser.Type, ShouldEqual, "Browser")
+		So(info.OS.Name, ShouldEqual, "Linux")
+
+		for _, ip := range []string{"5.9.1.1", "2a01:4f8::1"} {
+			ipInfo, err := u.LookupIP(net.ParseIP(ip))
+			So(err, ShouldBeNil)
+			So(ipInfo.DataCenter.NameCode, ShouldEqual, "hetzner")
+		}
+	})
+
+	Convey("reject invalid ranges", t, func() {
+		path := filepath.Join(t.TempDir(), "udgerdb_v3.dat")
+		d := udgertest.DB{DataCenterRanges: []udgertest.DataCenterRange{{From: "10.0.0.0", To: "::1"}}}
+		So(d.Write(path), ShouldNotBeNil)
+
+		d = udgertest.DB{DataCenterRanges: []udgertest.DataCenterRange{{From: "10.0.0", To: "10.0.0.1"}}}
+		So(d.Write(path+"2"), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,146 @@
+// Package udgertrace traces the udger lookups with OpenTelemetry spans.
+//
+//	c := udgertrace.New(u)
+//	info, err := c.Lookup(ctx, ua)
+package udgertrace
+
+import (
+	"context"
+	"net"
+	"net/netip"
+
+	"github.com/msales/udger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer.
+const instrumentationName = "github.com/msales/udger/udgertrace"
+
+// Attributes set on the spans.
+const (
+	BrowserFamilyKey = attribute.Key("udger.browser.family")
+	BrowserTypeKey   = attribute.Key("udger.browser.type")
+	DeviceClassKey   = attribute.Key("udger.device.class")
+	IPClassKey       = attribute.Key("udger.ip.class")
+	CrawlerKey       = attribute.Key("udger.crawler.family")
+	CacheHitKey      = attribute.Key("udger.cache_hit")
+)
+
+// Option configures the tracing.
+type Option func(*Client)
+
+// WithTracerProvider sets the provider of the tracer, the global provider by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// Client decorates an udger.Client with spans, children of the span of the given context.
+type Client struct {
+	client udger.Client
+	tracer trace.Tracer
+}
+
+// New decorates the client.
+func New(client udger.Client, opts ...Option) *Client {
+	c := &Client{
+		client: client,
+		tracer: otel.GetTracerProvider().Tracer(instrumentationName),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Lookup gathers information about the client using the provided user agent.
+func (c *Client) Lookup(ctx context.Context, ua string) (*udger.Info, error) {
+	_, span := c.tracer.Start(ctx, "udger.Lookup", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	info, err := c.client.Lookup(ua)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(
+		BrowserFamilyKey.String(info.Browser.Family),
+		BrowserTypeKey.String(info.Browser.Type),
+		DeviceClassKey.String(string(info.Device.Class)),
+		CacheHitKey.Bool(info.CacheHit),
+	)
+
+	return info, nil
+}
+
+// LookupIP gathers information about the client using the provided IP.
+func (c *Client) LookupIP(ctx context.Context, ip net.IP) (*udger.IPInfo, error) {
+	_, span := c.tracer.Start(ctx, "udger.LookupIP", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	info, err := c.client.LookupIP(ip)
+
+	return endIP(span, info, err)
+}
+
+// LookupAddr gathers information about the client using the provided address.
+func (c *Client) LookupAddr(ctx context.Context, addr netip.Addr) (*udger.IPInfo, error) {
+	_, span := c.tracer.Start(ctx, "udger.LookupAddr", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	info, err := c.client.LookupAddr(addr)
+
+	return endIP(span, info, err)
+}
+
+// Close releases the resources held by the decorated client.
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
+// WithContext returns an udger.Client tracing the lookups as children of the span of ctx,
+// for the code expecting an udger.Client.
+func (c *Client) WithContext(ctx context.Context) udger.Client {
+	return &boundClient{Client: c, ctx: ctx}
+}
+
+type boundClient struct {
+	*Client
+	ctx context.Context
+}
+
+func (b *boundClient) Lookup(ua string) (*udger.Info, error) {
+	return b.Client.Lookup(b.ctx, ua)
+}
+
+func (b *boundClient) LookupIP(ip net.IP) (*udger.IPInfo, error) {
+	return b.Client.LookupIP(b.ctx, ip)
+}
+
+func (b *boundClient) LookupAddr(addr netip.Addr) (*udger.IPInfo, error) {
+	return b.Client.LookupAddr(b.ctx, addr)
+}
+
+func endIP(span trace.Span, info *udger.IPInfo, err error) (*udger.IPInfo, error) {
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(
+		IPClassKey.String(info.IPClass.IPClassificationCode),
+		CrawlerKey.String(info.Crawler.FamilyCode),
+		CacheHitKey.Bool(info.CacheHit),
+	)
+
+	return info, nil
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
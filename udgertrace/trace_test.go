@@ -0,0 +1,96 @@
+package udgertrace_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgermocks"
+	"github.com/msales/udger/udgertrace"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value
+	}
+
+	return m
+}
+
+func TestClient(t *testing.T) {
+	Convey("trace the lookups", t, func() {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		parentCtx, parent := tp.Tracer("test").Start(context.Background(), "request")
+
+		m := &udgermocks.Client{}
+		c := udgertrace.New(m, udgertrace.WithTracerProvider(tp))
+
+		Convey("user agents", func() {
+			m.On("Lookup", "ua").Return(&udger.Info{
+				Browser:  udger.Browser{Family: "Chrome", Type: "Browser"},
+				Device:   udger.Device{Name: "Smartphone", Class: udger.DeviceClassSmartphone},
+				CacheHit: true,
+			}, nil)
+
+			info, err := c.Lookup(parentCtx, "ua")
+			So(err, ShouldBeNil)
+			So(info.Browser.Family, ShouldEqual, "Chrome")
+
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name, ShouldEqual, "udger.Lookup")
+			So(spans[0].Parent.SpanID(), ShouldEqual, parent.SpanContext().SpanID())
+			So(spans[0].SpanContext.TraceID(), ShouldEqual, parent.SpanContext().TraceID())
+			a := attrs(spans[0])
+			So(a[udgertrace.BrowserFamilyKey].AsString(), ShouldEqual, "Chrome")
+			So(a[udgertrace.DeviceClassKey].AsString(), ShouldEqual, "smartphone")
+			So(a[udgertrace.CacheHitKey].AsBool(), ShouldBeTrue)
+		})
+
+		Convey("addresses", func() {
+			addr := netip.MustParseAddr("66.249.64.1")
+			m.On("LookupAddr", addr).Return(&udger.IPInfo{
+				IPClass:  udger.IPClass{IPClassificationCode: "crawler"},
+				CacheHit: true,
+			}, nil)
+
+			_, err := c.WithContext(parentCtx).LookupAddr(addr)
+			So(err, ShouldBeNil)
+
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Name, ShouldEqual, "udger.LookupAddr")
+			So(spans[0].Parent.SpanID(), ShouldEqual, parent.SpanContext().SpanID())
+			a := attrs(spans[0])
+			So(a[udgertrace.IPClassKey].AsString(), ShouldEqual, "crawler")
+			So(a[udgertrace.CacheHitKey].AsBool(), ShouldBeTrue)
+		})
+
+		Convey("errors", func() {
+			ip := net.ParseIP("10.0.0.1")
+			m.On("LookupIP", ip).Return(nil, errors.New("closed"))
+
+			_, err := c.LookupIP(parentCtx, ip)
+			So(err, ShouldNotBeNil)
+
+			spans := exporter.GetSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Status.Code, ShouldEqual, codes.Error)
+			So(spans[0].Events, ShouldHaveLength, 1)
+		})
+
+		Reset(func() {
+			m.AssertExpectations(t)
+		})
+	})
+}
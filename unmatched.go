@@ -0,0 +1,133 @@
+package udger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultUnmatchedSize is the number of distinct user agents kept by an UnmatchedCollector when none is given.
+const DefaultUnmatchedSize = 1000
+
+// maxUnmatchedLength bounds the length of the user agents kept by an UnmatchedCollector.
+const maxUnmatchedLength = 512
+
+// Recognized tells which components of an Info were detected by a rule of the database.
+// An unrecognized device is derived from the client class or the operating system, see Info.DeviceSource.
+type Recognized struct {
+	Browser bool `json:"browser"`
+	OS      bool `json:"os"`
+	Device  bool `json:"device"`
+}
+
+// unmatched reports whether the client or the operating system is not recognized,
+// the crawlers matched by their user agent have no operating system.
+func (i *Info) unmatched() bool {
+	return !i.Recognized.Browser || (!i.Recognized.OS && !i.IsBot())
+}
+
+// UnmatchedHook is called by Lookup with the user agents whose client or operating system is not recognized,
+// except the crawlers. It must be safe for concurrent use.
+type UnmatchedHook func(ua string, r Recognized)
+
+// WithUnmatchedHook calls the hook on the user agents whose client or operating system is not recognized,
+// e.g. UnmatchedCollector.Record.
+func WithUnmatchedHook(hook UnmatchedHook) Option {
+	return func(o *options) {
+		o.unmatched = hook
+	}
+}
+
+// UnmatchedUA is a user agent recorded by an UnmatchedCollector.
+type UnmatchedUA struct {
+	UA         string     `json:"ua"`
+	Recognized Recognized `json:"recognized"`
+	Count      int        `json:"count"`
+}
+
+// UnmatchedCollector counts the distinct unrecognized user agents, up to a maximum number,
+// to export them periodically. It is safe for concurrent use.
+type UnmatchedCollector struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*UnmatchedUA
+	dropped int
+}
+
+// NewUnmatchedCollector creates a collector keeping up to size distinct user agents,
+// DefaultUnmatchedSize when size is not positive.
+func NewUnmatchedCollector(size int) *UnmatchedCollector {
+	if size <= 0 {
+		size = DefaultUnmatchedSize
+	}
+
+	return &UnmatchedCollector{
+		size:    size,
+		entries: make(map[string]*UnmatchedUA),
+	}
+}
+
+// Record counts the user agent. The new user agents are dropped when the collector is full,
+// the long ones are truncated.
+func (c *UnmatchedCollector) Record(ua string, r Recognized) {
+	if len(ua) > maxUnmatchedLength {
+		// cut on a rune boundary to keep the user agent valid UTF-8
+		n := maxUnmatchedLength
+		for n > 0 && !utf8.RuneStart(ua[n]) {
+			n--
+		}
+		ua = ua[:n]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[ua]; ok {
+		e.Count++
+		return
+	}
+	if len(c.entries) >= c.size {
+		c.dropped++
+		return
+	}
+	// copy the key, it may share the memory of a larger string of the caller
+	ua = strings.Clone(ua)
+	c.entries[ua] = &UnmatchedUA{UA: ua, Recognized: r, Count: 1}
+}
+
+// Unmatched returns the recorded user agents, the most frequent first.
+func (c *UnmatchedCollector) Unmatched() []UnmatchedUA {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sorted()
+}
+
+// Flush returns the recorded user agents, the most frequent first, and the number of occurrences
+// dropped because the collector was full, then resets the collector.
+func (c *UnmatchedCollector) Flush() ([]UnmatchedUA, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out, dropped := c.sorted(), c.dropped
+	c.entries = make(map[string]*UnmatchedUA)
+	c.dropped = 0
+
+	return out, dropped
+}
+
+func (c *UnmatchedCollector) sorted() []UnmatchedUA {
+	out := make([]UnmatchedUA, 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].UA < out[j].UA
+	})
+
+	return out
+}
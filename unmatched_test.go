@@ -0,0 +1,93 @@
+package udger_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/msales/udger"
+	"github.com/msales/udger/udgertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnmatchedCollector(t *testing.T) {
+	Convey("collect the unmatched user agents", t, func() {
+		c := udger.NewUnmatchedCollector(2)
+		c.Record("a", udger.Recognized{OS: true})
+		c.Record("b", udger.Recognized{})
+		c.Record("b", udger.Recognized{})
+		c.Record("c", udger.Recognized{})
+		c.Record(strings.Repeat("x", 1000), udger.Recognized{})
+
+		So(c.Unmatched(), ShouldResemble, []udger.UnmatchedUA{
+			{UA: "b", Count: 2},
+			{UA: "a", Recognized: udger.Recognized{OS: true}, Count: 1},
+		})
+
+		Convey("flush resets the collector", func() {
+			entries, dropped := c.Flush()
+			So(entries, ShouldHaveLength, 2)
+			So(dropped, ShouldEqual, 2)
+
+			c.Record("c", udger.Recognized{})
+			entries, dropped = c.Flush()
+			So(entries, ShouldResemble, []udger.UnmatchedUA{{UA: "c", Count: 1}})
+			So(dropped, ShouldEqual, 0)
+		})
+	})
+
+	Convey("truncate the long user agents on a rune boundary", t, func() {
+		c := udger.NewUnmatchedCollector(0)
+		c.Record(strings.Repeat("x", 1000), udger.Recognized{})
+		c.Record(strings.Repeat("y", 511)+strings.Repeat("é", 10), udger.Recognized{})
+
+		uas := make(map[string]bool)
+		for _, e := range c.Unmatched() {
+			So(utf8.ValidString(e.UA), ShouldBeTrue)
+			uas[e.UA] = true
+		}
+		So(uas, ShouldResemble, map[string]bool{
+			strings.Repeat("x", 512): true,
+			strings.Repeat("y", 511): true,
+		})
+	})
+
+	Convey("copy the recorded user agents", t, func() {
+		c := udger.NewUnmatchedCollector(0)
+		buf := []byte(strings.Repeat("z", 1000))
+		ua := *(*string)(unsafe.Pointer(&buf)) // shares the memory of buf
+		c.Record(ua, udger.Recognized{})
+		c.Record(ua[:10], udger.Recognized{})
+		copy(buf, strings.Repeat("w", len(buf)))
+
+		uas := make(map[string]bool)
+		for _, e := range c.Unmatched() {
+			uas[e.UA] = true
+		}
+		So(uas, ShouldResemble, map[string]bool{
+			strings.Repeat("z", 512): true,
+			strings.Repeat("z", 10):  true,
+		})
+	})
+
+	Convey("report the recognized components", t, func() {
+		c := udger.NewUnmatchedCollector(0)
+		u, err := udger.New(createTestDB(t), udger.WithDriver(udgertest.Driver), udger.WithUnmatchedHook(c.Record))
+		So(err, ShouldBeNil)
+
+		info, err := u.Lookup("Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)")
+		So(err, ShouldBeNil)
+		So(info.Recognized, ShouldResemble, udger.Recognized{Browser: true, OS: true})
+		So(c.Unmatched(), ShouldBeEmpty)
+
+		info, err = u.Lookup("unknown/1.0")
+		So(err, ShouldBeNil)
+		So(info.Recognized, ShouldResemble, udger.Recognized{})
+		So(info.Device.Name, ShouldEqual, "Personal computer")
+
+		_, err = u.Lookup("unknown/1.0")
+		So(err, ShouldBeNil)
+		So(c.Unmatched(), ShouldResemble, []udger.UnmatchedUA{{UA: "unknown/1.0", Count: 2}})
+	})
+}
@@ -0,0 +1,194 @@
+package udger
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// ErrUnknownCrawlerFamily is returned by Verify when no domains are known for the crawler family.
+var ErrUnknownCrawlerFamily = errors.New("udger: no domains known for the crawler family")
+
+// Resolver resolves addresses and host names, it is implemented by *net.Resolver.
+type Resolver interface {
+	// LookupAddr returns the host names of the address
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	// LookupNetIP returns the addresses of the host
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+var _ Resolver = (*net.Resolver)(nil)
+
+// DefaultCrawlerDomains maps the family codes of the crawlers from udger_crawler_list
+// to the domains their hosts belong to, as documented by their operators.
+var DefaultCrawlerDomains = map[string][]string{
+	// googleusercontent.com is not listed, any Google Cloud VM resolves in it
+	"googlebot":   {"googlebot.com", "google.com"},
+	"bingbot":     {"search.msn.com"},
+	"yandexbot":   {"yandex.ru", "yandex.net", "yandex.com"},
+	"baiduspider": {"baidu.com", "baidu.jp"},
+	"applebot":    {"applebot.apple.com"},
+	"duckduckbot": {"duckduckgo.com"},
+	"yahoo":       {"crawl.yahoo.net"},
+	"seznambot":   {"seznam.cz"},
+}
+
+// Default cache settings of the Verifier.
+const (
+	DefaultVerificationTTL         = time.Hour
+	DefaultNegativeVerificationTTL = 5 * time.Minute
+	DefaultVerificationCacheSize   = 10000
+)
+
+// VerifierOption configures a Verifier.
+type VerifierOption func(*Verifier)
+
+// WithResolver sets the resolver used by the Verifier, net.DefaultResolver is used by default.
+func WithResolver(r Resolver) VerifierOption {
+	return func(v *Verifier) {
+		v.resolver = r
+	}
+}
+
+// WithCrawlerDomains sets the domains allowed for the hosts of the crawler family, replacing the default ones.
+func WithCrawlerDomains(familyCode string, domains ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.domains[familyCode] = domains
+	}
+}
+
+// WithVerificationTTL sets how long the verified and the rejected results are cached.
+func WithVerificationTTL(ttl, negativeTTL time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.ttl = ttl
+		v.negativeTTL = negativeTTL
+	}
+}
+
+// WithVerificationCacheSize sets the number of results kept in the cache.
+func WithVerificationCacheSize(size int) VerifierOption {
+	return func(v *Verifier) {
+		v.cacheSize = size
+	}
+}
+
+// Verifier checks that an IP really belongs to a crawler with a reverse DNS lookup, checking the
+// host name against the domains of the crawler family, confirmed by a forward DNS lookup.
+// It is safe for concurrent use.
+type Verifier struct {
+	resolver    Resolver
+	domains     map[string][]string
+	ttl         time.Duration
+	negativeTTL time.Duration
+	cacheSize   int
+	cache       *lru[verifyKey, verifyEntry]
+	now         func() time.Time
+}
+
+type verifyKey struct {
+	family string
+	addr   netip.Addr
+}
+
+type verifyEntry struct {
+	verified bool
+	expires  time.Time
+}
+
+// NewVerifier creates a new Verifier.
+func NewVerifier(opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		resolver:    net.DefaultResolver,
+		domains:     make(map[string][]string, len(DefaultCrawlerDomains)),
+		ttl:         DefaultVerificationTTL,
+		negativeTTL: DefaultNegativeVerificationTTL,
+		cacheSize:   DefaultVerificationCacheSize,
+		now:         time.Now,
+	}
+	copyMap(v.domains, DefaultCrawlerDomains)
+	for _, opt := range opts {
+		opt(v)
+	}
+	v.cache = newLRU[verifyKey, verifyEntry](v.cacheSize)
+
+	return v
+}
+
+// Verify reports whether the address belongs to the crawler family, e.g. Crawler.FamilyCode.
+// DNS failures other than not found are returned as errors and are not cached.
+func (v *Verifier) Verify(ctx context.Context, familyCode string, addr netip.Addr) (bool, error) {
+	domains, ok := v.domains[familyCode]
+	if !ok || len(domains) == 0 {
+		return false, ErrUnknownCrawlerFamily
+	}
+	addr = CanonicalAddr(addr)
+
+	key := verifyKey{family: familyCode, addr: addr}
+	if e, ok := v.cache.get(key); ok && v.now().Before(e.expires) {
+		return e.verified, nil
+	}
+
+	verified, err := v.verify(ctx, domains, addr)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := v.ttl
+	if !verified {
+		ttl = v.negativeTTL
+	}
+	v.cache.add(key, verifyEntry{verified: verified, expires: v.now().Add(ttl)})
+
+	return verified, nil
+}
+
+func (v *Verifier) verify(ctx context.Context, domains []string, addr netip.Addr) (bool, error) {
+	names, err := v.resolver.LookupAddr(ctx, addr.String())
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if !matchDomain(name, domains) {
+			continue
+		}
+
+		addrs, err := v.resolver.LookupNetIP(ctx, "ip", name)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		for _, a := range addrs {
+			if CanonicalAddr(a) == addr {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func matchDomain(name string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.Trim(d, "."))
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
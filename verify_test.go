@@ -0,0 +1,132 @@
+package udger
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type stubResolver struct {
+	names   map[string][]string
+	addrs   map[string][]netip.Addr
+	err     error
+	lookups int
+}
+
+func (r *stubResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	names, ok := r.names[addr]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+
+	return names, nil
+}
+
+func (r *stubResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	addrs, ok := r.addrs[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return addrs, nil
+}
+
+func TestVerifier(t *testing.T) {
+	Convey("verify crawlers", t, func() {
+		r := &stubResolver{
+			names: map[string][]string{
+				"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."},
+				"6.6.6.6":     {"crawl-6-6-6-6.googlebot.com.evil.com."},
+				"7.7.7.7":     {"crawl-7-7-7-7.googlebot.com."},
+				"34.1.2.3":    {"3.2.1.34.bc.googleusercontent.com."},
+			},
+			addrs: map[string][]netip.Addr{
+				"crawl-66-249-66-1.googlebot.com":      {netip.MustParseAddr("::ffff:66.249.66.1")},
+				"crawl-6-6-6-6.googlebot.com.evil.com": {netip.MustParseAddr("6.6.6.6")},
+				"crawl-7-7-7-7.googlebot.com":          {netip.MustParseAddr("66.249.66.7")},
+				"3.2.1.34.bc.googleusercontent.com":    {netip.MustParseAddr("34.1.2.3")},
+			},
+		}
+		now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+		v := NewVerifier(WithResolver(r), WithVerificationTTL(time.Hour, time.Minute))
+		v.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		Convey("a real crawler is verified and cached", func() {
+			ok, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = v.Verify(ctx, "googlebot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(r.lookups, ShouldEqual, 1)
+
+			now = now.Add(2 * time.Hour)
+			_, err = v.Verify(ctx, "googlebot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldBeNil)
+			So(r.lookups, ShouldEqual, 2)
+		})
+
+		Convey("a host outside of the crawler domains is rejected", func() {
+			ok, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("6.6.6.6"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("a forward confirmed cloud VM of Google is rejected", func() {
+			ok, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("34.1.2.3"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("a host not resolving to the address is rejected", func() {
+			ok, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("7.7.7.7"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("an address without host is rejected and cached for the negative ttl", func() {
+			ok, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("8.8.8.8"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			now = now.Add(30 * time.Second)
+			_, _ = v.Verify(ctx, "googlebot", netip.MustParseAddr("8.8.8.8"))
+			So(r.lookups, ShouldEqual, 1)
+
+			now = now.Add(time.Minute)
+			_, _ = v.Verify(ctx, "googlebot", netip.MustParseAddr("8.8.8.8"))
+			So(r.lookups, ShouldEqual, 2)
+		})
+
+		Convey("the domains are checked per family", func() {
+			ok, err := v.Verify(ctx, "bingbot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			_, err = v.Verify(ctx, "unknownbot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldEqual, ErrUnknownCrawlerFamily)
+		})
+
+		Convey("resolver failures are returned and not cached", func() {
+			r.err = errors.New("timeout")
+			_, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldNotBeNil)
+
+			r.err = nil
+			ok, err := v.Verify(ctx, "googlebot", netip.MustParseAddr("66.249.66.1"))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		})
+	})
+}